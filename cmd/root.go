@@ -4,6 +4,10 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/mucansever/gittree/internal/list"
+	"github.com/mucansever/gittree/internal/prune"
+	"github.com/mucansever/gittree/internal/report"
+	"github.com/mucansever/gittree/internal/restack"
+	"github.com/mucansever/gittree/internal/setparent"
 )
 
 var rootCmd = &cobra.Command{
@@ -23,5 +27,9 @@ func Execute() error {
 
 func init() {
 	rootCmd.AddCommand(list.NewListCommand())
-	rootCmd.Flags().StringVarP(&uiPath, "path", "p", ".", "Path to the git repository")
+	rootCmd.AddCommand(prune.NewPruneCommand())
+	rootCmd.AddCommand(report.NewReportCommand())
+	rootCmd.AddCommand(restack.NewRestackCommand())
+	rootCmd.AddCommand(setparent.NewSetParentCommand())
+	addUIFlags(rootCmd)
 }
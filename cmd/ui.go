@@ -1,18 +1,29 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
-	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/spf13/cobra"
 
+	"github.com/mucansever/gittree/internal/branchtree"
 	"github.com/mucansever/gittree/internal/git"
 	"github.com/mucansever/gittree/internal/tree"
 	"github.com/mucansever/gittree/internal/tui"
 )
 
-var uiPath string
+var (
+	uiPath         string
+	uiRemotes      bool
+	uiAll          bool
+	uiInfer        string
+	uiTrunk        string
+	uiDAG          bool
+	uiSquashMerged bool
+	uiSort         string
+	uiFormat       string
+)
 
 var uiCmd = &cobra.Command{
 	Use:   "ui",
@@ -23,49 +34,66 @@ var uiCmd = &cobra.Command{
 
 func init() {
 	rootCmd.AddCommand(uiCmd)
-	uiCmd.Flags().StringVarP(&uiPath, "path", "p", ".", "Path to the git repository")
+	addUIFlags(uiCmd)
 }
 
-func runUI(cmd *cobra.Command, args []string) error {
-	repo, err := git.Open(uiPath)
-	if err != nil {
-		return fmt.Errorf("failed to open repository: %w", err)
-	}
-
-	branches, err := repo.GetBranches()
-	if err != nil {
-		return fmt.Errorf("failed to get branches: %w", err)
-	}
+// registers the UI flags on cmd; both the root command and ui share them
+func addUIFlags(cmd *cobra.Command) {
+	cmd.PreRunE = validateUIFlags
+	cmd.Flags().StringVarP(&uiPath, "path", "p", ".", "Path to the git repository")
+	cmd.Flags().BoolVarP(&uiRemotes, "remotes", "r", false, "Show remote-tracking branches only")
+	cmd.Flags().BoolVarP(&uiAll, "all", "a", false, "Show both local and remote-tracking branches")
+	cmd.Flags().StringVar(&uiInfer, "infer", string(branchtree.InferAncestry),
+		"How branch parents are inferred: ancestry, fork-point or reflog")
+	cmd.Flags().StringVar(&uiTrunk, "trunk", "",
+		"Branch that merged branches are detected against (default main or master)")
+	cmd.Flags().BoolVar(&uiDAG, "dag", false,
+		"Show every branch once, listing further parents as \"also from\"")
+	cmd.Flags().BoolVar(&uiSquashMerged, "squash-merged", false,
+		"Tag branches that were squash- or rebase-merged into the trunk")
+	cmd.Flags().StringVar(&uiSort, "sort", string(tree.SortName),
+		"Order of sibling branches: name, date, creation or ahead")
+	cmd.Flags().StringVar(&uiFormat, "format", "",
+		"Template for branch labels, or one of the presets short, oneline and full")
+}
 
-	if len(branches) == 0 {
-		fmt.Println("No branches found")
-		return nil
+// reports bad flag values before the repository is opened
+func validateUIFlags(cmd *cobra.Command, args []string) error {
+	if err := tree.SortOrder(uiSort).Validate(); err != nil {
+		return err
 	}
+	return branchtree.Inference(uiInfer).Validate()
+}
 
-	currentBranch, err := repo.GetCurrentBranch()
+func runUI(cmd *cobra.Command, args []string) error {
+	format, err := tree.ParseFormat(uiFormat)
 	if err != nil {
-		return fmt.Errorf("failed to get current branch: %w", err)
+		return err
 	}
 
-	relationships, err := repo.GetBranchRelationships(branches)
+	repo, err := git.Open(uiPath)
 	if err != nil {
-		return fmt.Errorf("failed to analyze branch relationships: %w", err)
+		return fmt.Errorf("failed to open repository: %w", err)
 	}
 
-	meta := make(map[string]time.Time)
-	for _, b := range branches {
-		meta[b.Name] = b.Commit.Committer.When
+	t, err := branchtree.Load(repo, branchtree.Options{
+		Remotes:      uiRemotes,
+		All:          uiAll,
+		Inference:    branchtree.Inference(uiInfer),
+		Trunk:        uiTrunk,
+		DAG:          uiDAG,
+		Sort:         tree.SortOrder(uiSort),
+		SquashMerged: uiSquashMerged,
+	})
+	if errors.Is(err, branchtree.ErrNoBranches) {
+		fmt.Println("No branches found")
+		return nil
 	}
-
-	builder := tree.NewBuilder(relationships, meta)
-	t, err := builder.Build(currentBranch)
 	if err != nil {
-		return fmt.Errorf("failed to build tree: %w", err)
+		return err
 	}
 
-	items := tree.Flatten(t)
-
-	p := tea.NewProgram(tui.NewModel(items, repo))
+	p := tea.NewProgram(tui.NewModel(t, format, repo))
 	if _, err := p.Run(); err != nil {
 		return fmt.Errorf("error running TUI: %w", err)
 	}
@@ -0,0 +1,327 @@
+package branchtree
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing"
+
+	"github.com/mucansever/gittree/internal/git"
+	"github.com/mucansever/gittree/internal/tree"
+)
+
+var (
+	ErrNoBranches       = errors.New("no branches found")
+	ErrUnknownInference = errors.New("unknown inference mode")
+	ErrTrunkNotFound    = errors.New("trunk branch not found")
+)
+
+// Inference selects how a branch's parent is determined.
+type Inference string
+
+const (
+	// parent tip is an ancestor of the branch
+	InferAncestry Inference = "ancestry"
+	// parent shares the most recent merge-base with the branch
+	InferForkPoint Inference = "fork-point"
+	// parent is the branch the reflog says the branch was created from
+	InferReflog Inference = "reflog"
+)
+
+// Validate returns ErrUnknownInference unless i is one of the inference
+// modes.
+func (i Inference) Validate() error {
+	switch i {
+	case "", InferAncestry, InferForkPoint, InferReflog:
+		return nil
+	}
+	return fmt.Errorf("%w: %q", ErrUnknownInference, i)
+}
+
+// branches considered the trunk when none is configured, in order of preference
+var defaultTrunks = []string{"main", "master", "origin/main", "origin/master"}
+
+// Options selects which branches end up in the tree and how they are arranged.
+type Options struct {
+	Remotes   bool
+	All       bool
+	Inference Inference
+	Trunk     string
+	// draw every branch once, listing further parents as cross-links
+	DAG bool
+	// order of sibling branches, by name when empty
+	Sort tree.SortOrder
+	// tag squash- and rebase-merged branches, which compares the patch of
+	// every trunk commit and is slow on long histories
+	SquashMerged bool
+}
+
+// Load reads the branches of repo and arranges them into a tree.
+func Load(repo *git.Repository, opts Options) (*tree.Tree, error) {
+	branches, err := loadBranches(repo, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get branches: %w", err)
+	}
+
+	if len(branches) == 0 {
+		return nil, ErrNoBranches
+	}
+
+	currentBranch, err := repo.GetCurrentBranch()
+	if errors.Is(err, git.ErrDetachedHead) {
+		// place the detached HEAD in the tree like any other branch, as the
+		// one that is checked out
+		head, err := repo.GetDetachedHead()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get detached HEAD: %w", err)
+		}
+		branches = append(branches, head)
+		currentBranch = git.DetachedHeadName
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to get current branch: %w", err)
+	}
+
+	trunk, err := FindTrunk(branches, opts.Trunk)
+	if err != nil && opts.Trunk != "" {
+		return nil, err
+	}
+
+	builder, err := newBuilder(repo, branches, trunk.Name, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// a detached HEAD keeps its own node even at a branch tip, below the
+	// branch there
+	tips := make(map[string]string, len(branches))
+	for _, b := range branches {
+		if b.Detached {
+			builder.SetDetachedHead(b.Name, b.Hash.String())
+			continue
+		}
+		tips[b.Name] = b.Hash.String()
+	}
+	builder.SetTrunk(trunk.Name)
+	builder.SetTips(tips)
+	builder.SetCommits(tipCommits(branches))
+	builder.SetDAG(opts.DAG)
+
+	sources, err := repo.ParentSources()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read parent sources: %w", err)
+	}
+	parents, err := repo.GetRecordedParents(sources)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read recorded parents: %w", err)
+	}
+	builder.SetRecordedParents(parents)
+
+	t, err := builder.Build(currentBranch)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build tree: %w", err)
+	}
+
+	if err := annotateCounts(repo, t, branches); err != nil {
+		return nil, fmt.Errorf("failed to count commits: %w", err)
+	}
+
+	if trunk.Name != "" {
+		merged, err := repo.GetMergedBranches(branches, trunk)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find merged branches: %w", err)
+		}
+		var squashMerged map[string]bool
+		if opts.SquashMerged {
+			squashMerged, err = repo.GetSquashMergedBranches(branches, trunk)
+			if err != nil {
+				return nil, fmt.Errorf("failed to find squash-merged branches: %w", err)
+			}
+		}
+		t.Walk(func(node *tree.Node) {
+			node.Merged = merged[node.Name]
+			node.SquashMerged = squashMerged[node.Name]
+		})
+	}
+
+	created, err := repo.GetBranchCreationTimes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read branch reflogs: %w", err)
+	}
+	byName := make(map[string]git.Branch, len(branches))
+	for _, b := range branches {
+		byName[b.Name] = b
+	}
+	t.Walk(func(node *tree.Node) {
+		node.Created = created[node.Name]
+		node.IsRemote = byName[node.Name].Remote
+		node.IsDetached = byName[node.Name].Detached
+	})
+
+	if err := tree.Sort(t, opts.Sort); err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+func newBuilder(repo *git.Repository, branches []git.Branch, trunk string, opts Options) (*tree.Builder, error) {
+	meta := make(map[string]time.Time)
+	for _, b := range branches {
+		meta[b.Name] = b.Commit.Committer.When
+	}
+
+	switch opts.Inference {
+	case "", InferAncestry:
+		relationships, err := repo.GetBranchRelationships(branches)
+		if err != nil {
+			return nil, fmt.Errorf("failed to analyze branch relationships: %w", err)
+		}
+		return tree.NewBuilder(relationships, meta), nil
+
+	case InferReflog:
+		relationships, err := repo.GetBranchRelationships(branches)
+		if err != nil {
+			return nil, fmt.Errorf("failed to analyze branch relationships: %w", err)
+		}
+		sources, err := repo.GetBranchSources()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read branch reflogs: %w", err)
+		}
+
+		builder := tree.NewBuilder(relationships, meta)
+		builder.SetCreationSources(sources)
+		return builder, nil
+
+	case InferForkPoint:
+		// once a parent moves on, it and its child both diverged from the
+		// same commit, and only the reflog tells which one made it; once it
+		// is amended or rebased, only its reflog still leads to that commit
+		formerTips, err := repo.GetFormerTips()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read branch reflogs: %w", err)
+		}
+		forkPoints, err := repo.GetForkPoints(branches, formerTips)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find fork points: %w", err)
+		}
+
+		hashes := make(map[string]plumbing.Hash, len(branches))
+		relationships := make(map[string]map[string]bool, len(branches))
+		for _, b := range branches {
+			hashes[b.Name] = b.Hash
+			relationships[b.Name] = make(map[string]bool)
+		}
+
+		converted := make(map[string][]tree.ForkPoint, len(forkPoints))
+		for name, points := range forkPoints {
+			for _, fp := range points {
+				converted[name] = append(converted[name], tree.ForkPoint{
+					Parent:           fp.Branch,
+					Rank:             fp.Rank,
+					AtParentTip:      fp.Hash == hashes[fp.Branch],
+					AtBranchTip:      fp.Hash == hashes[name],
+					BranchMovedThere: formerTips[name][fp.Hash] && !formerTips[fp.Branch][fp.Hash],
+				})
+			}
+		}
+
+		builder := tree.NewBuilder(relationships, meta)
+		builder.SetForkPoints(converted, trunk)
+		return builder, nil
+
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownInference, opts.Inference)
+	}
+}
+
+func tipCommits(branches []git.Branch) map[string]tree.Commit {
+	commits := make(map[string]tree.Commit, len(branches))
+	for _, b := range branches {
+		subject, _, _ := strings.Cut(b.Commit.Message, "\n")
+		commits[b.Name] = tree.Commit{
+			Hash:        b.Hash.String(),
+			Subject:     subject,
+			AuthorName:  b.Commit.Author.Name,
+			AuthorEmail: b.Commit.Author.Email,
+		}
+	}
+	return commits
+}
+
+// FindTrunk returns the configured trunk branch or, when none is configured,
+// the first of main, master, origin/main and origin/master that exists.
+func FindTrunk(branches []git.Branch, configured string) (git.Branch, error) {
+	byName := make(map[string]git.Branch, len(branches))
+	for _, b := range branches {
+		byName[b.Name] = b
+	}
+
+	candidates := defaultTrunks
+	if configured != "" {
+		candidates = []string{configured}
+	}
+	for _, name := range candidates {
+		if b, ok := byName[name]; ok {
+			return b, nil
+		}
+	}
+
+	if configured != "" {
+		return git.Branch{}, fmt.Errorf("%w: %s", ErrTrunkNotFound, configured)
+	}
+	return git.Branch{}, ErrTrunkNotFound
+}
+
+func loadBranches(repo *git.Repository, opts Options) ([]git.Branch, error) {
+	var branches []git.Branch
+
+	if !opts.Remotes || opts.All {
+		local, err := repo.GetBranches()
+		if err != nil {
+			return nil, err
+		}
+		branches = append(branches, local...)
+	}
+
+	if opts.Remotes || opts.All {
+		remote, err := repo.GetRemoteBranches()
+		if err != nil {
+			return nil, err
+		}
+		branches = append(branches, remote...)
+	}
+
+	return branches, nil
+}
+
+// sets ahead/behind counts of every node relative to its tree parent
+func annotateCounts(repo *git.Repository, t *tree.Tree, branches []git.Branch) error {
+	byName := make(map[string]git.Branch, len(branches))
+	for _, b := range branches {
+		byName[b.Name] = b
+	}
+
+	var walk func(parent *tree.Node) error
+	walk = func(parent *tree.Node) error {
+		parentBranch, hasParent := byName[parent.Name]
+
+		for _, child := range parent.Children {
+			if childBranch, ok := byName[child.Name]; ok && hasParent {
+				ahead, behind, err := repo.AheadBehind(parentBranch, childBranch)
+				if err != nil {
+					return err
+				}
+				child.Ahead, child.Behind = ahead, behind
+			}
+
+			if err := walk(child); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	return walk(t.Root)
+}
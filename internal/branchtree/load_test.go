@@ -0,0 +1,622 @@
+package branchtree
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mucansever/gittree/internal/git"
+	"github.com/mucansever/gittree/internal/tree"
+)
+
+func TestLoad(t *testing.T) {
+	path := createTestRepo(t)
+	repo := openRepo(t, path)
+
+	createBranch(t, repo, "feature")
+	checkoutBranch(t, repo, "feature")
+	commitFile(t, repo, "file1.txt", "content1")
+	commitFile(t, repo, "file2.txt", "content2")
+	createBranch(t, repo, "bugfix")
+	checkoutBranch(t, repo, "bugfix")
+	commitFile(t, repo, "file3.txt", "content3")
+
+	r, err := git.Open(path)
+	require.NoError(t, err)
+
+	tr, err := Load(r, Options{})
+	require.NoError(t, err)
+
+	require.Len(t, tr.Root.Children, 1)
+	master := tr.Root.Children[0]
+	assert.Equal(t, "master", master.Name)
+	assert.Zero(t, master.Ahead)
+
+	require.Len(t, master.Children, 1)
+	feature := master.Children[0]
+	assert.Equal(t, "feature", feature.Name)
+	assert.Equal(t, 2, feature.Ahead)
+	assert.Zero(t, feature.Behind)
+
+	require.Len(t, feature.Children, 1)
+	bugfix := feature.Children[0]
+	assert.Equal(t, "bugfix", bugfix.Name)
+	assert.True(t, bugfix.IsCurrent)
+	assert.False(t, feature.IsCurrent)
+	assert.False(t, bugfix.IsRemote)
+	assert.Equal(t, 1, bugfix.Ahead)
+	assert.Equal(t, "Add file3.txt", bugfix.Subject)
+	assert.Equal(t, "Test User", bugfix.Author)
+	assert.Equal(t, "test@example.com", bugfix.AuthorEmail)
+	assert.Len(t, bugfix.Hash, 40)
+}
+
+func TestLoad_DetachedHead(t *testing.T) {
+	path := createTestRepo(t)
+	repo := openRepo(t, path)
+
+	createBranch(t, repo, "feature")
+	checkoutBranch(t, repo, "feature")
+	commitFile(t, repo, "file1.txt", "content1")
+	commitFile(t, repo, "file2.txt", "content2")
+
+	// detach one commit below the feature tip, as during a rebase or bisect
+	head, err := repo.Head()
+	require.NoError(t, err)
+	tip, err := repo.CommitObject(head.Hash())
+	require.NoError(t, err)
+	w, err := repo.Worktree()
+	require.NoError(t, err)
+	require.NoError(t, w.Checkout(&gogit.CheckoutOptions{Hash: tip.ParentHashes[0]}))
+
+	r, err := git.Open(path)
+	require.NoError(t, err)
+
+	tr, err := Load(r, Options{})
+	require.NoError(t, err)
+
+	master := tr.Root.Children[0]
+	assert.Equal(t, "master", master.Name)
+	require.Len(t, master.Children, 1)
+
+	detached := master.Children[0]
+	assert.Equal(t, git.DetachedHeadName, detached.Name)
+	assert.Equal(t, tip.ParentHashes[0].String(), detached.Hash)
+	assert.Equal(t, 1, detached.Ahead)
+	assert.True(t, detached.IsDetached)
+	assert.True(t, detached.IsCurrent)
+
+	require.Len(t, detached.Children, 1)
+	assert.Equal(t, "feature", detached.Children[0].Name)
+	assert.Equal(t, 1, detached.Children[0].Ahead)
+}
+
+func TestLoad_ForkPoint(t *testing.T) {
+	path := createTestRepo(t)
+	repo := openRepo(t, path)
+
+	createBranch(t, repo, "feature")
+	checkoutBranch(t, repo, "feature")
+	commitFile(t, repo, "file1.txt", "content1")
+	checkoutBranch(t, repo, "master")
+	commitFile(t, repo, "file2.txt", "content2")
+	commitFile(t, repo, "file3.txt", "content3")
+
+	r, err := git.Open(path)
+	require.NoError(t, err)
+
+	// by ancestry the trunk moving on makes feature a top-level branch
+	tr, err := Load(r, Options{Inference: InferAncestry})
+	require.NoError(t, err)
+	assert.Len(t, tr.Root.Children, 2)
+
+	tr, err = Load(r, Options{Inference: InferForkPoint})
+	require.NoError(t, err)
+
+	require.Len(t, tr.Root.Children, 1)
+	master := tr.Root.Children[0]
+	assert.Equal(t, "master", master.Name)
+	assert.True(t, master.IsCurrent)
+
+	require.Len(t, master.Children, 1)
+	feature := master.Children[0]
+	assert.Equal(t, "feature", feature.Name)
+	assert.Equal(t, 1, feature.Ahead)
+	assert.Equal(t, 2, feature.Behind)
+}
+
+func TestLoad_ForkPointRemoteTracking(t *testing.T) {
+	path := createTestRepo(t)
+	repo := openRepo(t, path)
+	m := branchHash(t, repo, "master")
+
+	// feat was pushed at f1 and has moved on since
+	createBranch(t, repo, "feat")
+	checkoutBranch(t, repo, "feat")
+	commitFile(t, repo, "file1.txt", "content1")
+	f1 := branchHash(t, repo, "feat")
+	require.NoError(t, repo.Storer.SetReference(plumbing.NewHashReference(
+		plumbing.NewRemoteReferenceName("origin", "feat"), f1)))
+	commitFile(t, repo, "file2.txt", "content2")
+	f2 := branchHash(t, repo, "feat")
+
+	writeReflog(t, path, "refs/heads/feat",
+		reflogLine{m, "branch: Created from master"},
+		reflogLine{f1, "commit: Add file1.txt"},
+		reflogLine{f2, "commit: Add file2.txt"})
+	writeReflog(t, path, "refs/remotes/origin/feat",
+		reflogLine{f1, "update by push"})
+
+	r, err := git.Open(path)
+	require.NoError(t, err)
+
+	tr, err := Load(r, Options{All: true, Inference: InferForkPoint})
+	require.NoError(t, err)
+
+	// feat moved to f1 but so did origin/feat, so feat builds on it
+	require.Len(t, tr.Root.Children, 1)
+	master := tr.Root.Children[0]
+	require.Len(t, master.Children, 1)
+	remote := master.Children[0]
+	assert.Equal(t, "origin/feat", remote.Name)
+	require.Len(t, remote.Children, 1)
+	assert.Equal(t, "feat", remote.Children[0].Name)
+	assert.Equal(t, 1, remote.Children[0].Ahead)
+}
+
+func TestLoad_Merged(t *testing.T) {
+	path := createTestRepo(t)
+	repo := openRepo(t, path)
+
+	createBranch(t, repo, "done")
+	commitFile(t, repo, "file1.txt", "content1")
+	createBranch(t, repo, "wip")
+	checkoutBranch(t, repo, "wip")
+	commitFile(t, repo, "file2.txt", "content2")
+
+	r, err := git.Open(path)
+	require.NoError(t, err)
+
+	tr, err := Load(r, Options{})
+	require.NoError(t, err)
+
+	merged := make(map[string]bool)
+	tr.Walk(func(node *tree.Node) {
+		merged[node.Name] = node.Merged
+	})
+	assert.Equal(t, map[string]bool{".": false, "done": true, "master": false, "wip": false}, merged)
+
+	// against wip, master is merged as well
+	tr, err = Load(r, Options{Trunk: "wip"})
+	require.NoError(t, err)
+	tr.Walk(func(node *tree.Node) {
+		merged[node.Name] = node.Merged
+	})
+	assert.True(t, merged["master"])
+
+	_, err = Load(r, Options{Trunk: "missing"})
+	assert.ErrorIs(t, err, ErrTrunkNotFound)
+}
+
+func TestLoad_DetachedHeadAtBranchTip(t *testing.T) {
+	path := createTestRepo(t)
+	repo := openRepo(t, path)
+
+	createBranch(t, repo, "feature")
+	checkoutBranch(t, repo, "feature")
+	commitFile(t, repo, "file1.txt", "content1")
+	createBranch(t, repo, "child")
+	checkoutBranch(t, repo, "child")
+	commitFile(t, repo, "file2.txt", "content2")
+
+	// detach at the feature tip, which child builds on
+	ref, err := repo.Reference(plumbing.NewBranchReferenceName("feature"), true)
+	require.NoError(t, err)
+	w, err := repo.Worktree()
+	require.NoError(t, err)
+	require.NoError(t, w.Checkout(&gogit.CheckoutOptions{Hash: ref.Hash()}))
+
+	r, err := git.Open(path)
+	require.NoError(t, err)
+
+	for _, inference := range []Inference{InferAncestry, InferForkPoint} {
+		t.Run(string(inference), func(t *testing.T) {
+			tr, err := Load(r, Options{Inference: inference})
+			require.NoError(t, err)
+
+			// every branch shows up once
+			seen := make(map[string]int)
+			tr.Walk(func(node *tree.Node) { seen[node.Name]++ })
+			assert.Equal(t, map[string]int{".": 1, "master": 1, "feature": 1, "HEAD": 1, "child": 1}, seen)
+
+			feature := tr.Root.Children[0].Children[0]
+			require.Equal(t, "feature", feature.Name)
+			var children []string
+			for _, child := range feature.Children {
+				children = append(children, child.Name)
+			}
+			assert.ElementsMatch(t, []string{"HEAD", "child"}, children)
+		})
+	}
+}
+
+func TestLoad_SquashMerged(t *testing.T) {
+	path := createTestRepo(t)
+	repo := openRepo(t, path)
+
+	createBranch(t, repo, "squashed")
+	checkoutBranch(t, repo, "squashed")
+	commitFile(t, repo, "file1.txt", "content1")
+	checkoutBranch(t, repo, "master")
+	createBranch(t, repo, "wip")
+	checkoutBranch(t, repo, "wip")
+	commitFile(t, repo, "file2.txt", "content2")
+
+	// land squashed on master as a new commit with the same change
+	checkoutBranch(t, repo, "master")
+	commitFile(t, repo, "file3.txt", "content3")
+	commitFile(t, repo, "file1.txt", "content1")
+
+	r, err := git.Open(path)
+	require.NoError(t, err)
+
+	// patch-ids are only compared when asked for
+	tr, err := Load(r, Options{})
+	require.NoError(t, err)
+	tr.Walk(func(node *tree.Node) {
+		assert.False(t, node.SquashMerged, node.Name)
+	})
+
+	tr, err = Load(r, Options{SquashMerged: true})
+	require.NoError(t, err)
+
+	squashMerged := make(map[string]bool)
+	tr.Walk(func(node *tree.Node) {
+		squashMerged[node.Name] = node.SquashMerged
+		assert.False(t, node.Merged, node.Name)
+	})
+	assert.Equal(t, map[string]bool{".": false, "squashed": true, "master": false, "wip": false}, squashMerged)
+}
+
+func TestLoad_RecordedParents(t *testing.T) {
+	path := createTestRepo(t)
+	repo := openRepo(t, path)
+
+	// two branches at the same commit cannot be told apart by ancestry
+	commitFile(t, repo, "file1.txt", "content1")
+	createBranch(t, repo, "feat-a")
+	createBranch(t, repo, "feat-b")
+
+	r, err := git.Open(path)
+	require.NoError(t, err)
+	require.NoError(t, r.SetBranchParent("feat-a", "master"))
+	require.NoError(t, r.SetBranchParent("feat-b", "feat-a"))
+
+	for _, inference := range []Inference{InferAncestry, InferForkPoint} {
+		tr, err := Load(r, Options{Inference: inference})
+		require.NoError(t, err)
+
+		require.Len(t, tr.Root.Children, 1, inference)
+		master := tr.Root.Children[0]
+		require.Len(t, master.Children, 1, inference)
+		featA := master.Children[0]
+		assert.Equal(t, "feat-a", featA.Name)
+		assert.True(t, featA.Recorded)
+		require.Len(t, featA.Children, 1, inference)
+		assert.Equal(t, "feat-b", featA.Children[0].Name)
+		assert.True(t, featA.Children[0].Recorded)
+	}
+}
+
+func TestLoad_Aliases(t *testing.T) {
+	path := createTestRepo(t)
+	repo := openRepo(t, path)
+
+	createBranch(t, repo, "no-commit")
+	createBranch(t, repo, "feature")
+	checkoutBranch(t, repo, "feature")
+	commitFile(t, repo, "file1.txt", "content1")
+	createBranch(t, repo, "feature-copy")
+	checkoutBranch(t, repo, "no-commit")
+
+	r, err := git.Open(path)
+	require.NoError(t, err)
+
+	for _, inference := range []Inference{InferAncestry, InferForkPoint, InferReflog} {
+		tr, err := Load(r, Options{Inference: inference})
+		require.NoError(t, err)
+
+		require.Len(t, tr.Root.Children, 1, inference)
+		master := tr.Root.Children[0]
+		assert.Equal(t, "master", master.Name)
+		assert.Equal(t, []string{"no-commit"}, master.Aliases)
+		assert.True(t, master.IsCurrent)
+		assert.Equal(t, "no-commit", master.CurrentAlias)
+
+		require.Len(t, master.Children, 1, inference)
+		feature := master.Children[0]
+		assert.Equal(t, "feature", feature.Name)
+		assert.Equal(t, []string{"feature-copy"}, feature.Aliases)
+		assert.Equal(t, 1, feature.Ahead)
+		assert.Empty(t, feature.Children)
+	}
+}
+
+func TestLoad_GitTownParents(t *testing.T) {
+	path := createTestRepo(t)
+	repo := openRepo(t, path)
+
+	commitFile(t, repo, "file1.txt", "content1")
+	createBranch(t, repo, "feat-a")
+	createBranch(t, repo, "feat-b")
+
+	cfg, err := repo.Config()
+	require.NoError(t, err)
+	cfg.Raw.Section("git-town-branch").Subsection("feat-a").SetOption("parent", "master")
+	cfg.Raw.Section("git-town-branch").Subsection("feat-b").SetOption("parent", "feat-a")
+	require.NoError(t, repo.SetConfig(cfg))
+
+	r, err := git.Open(path)
+	require.NoError(t, err)
+
+	tr, err := Load(r, Options{})
+	require.NoError(t, err)
+
+	master := tr.Root.Children[0]
+	require.Len(t, master.Children, 1)
+	featA := master.Children[0]
+	assert.Equal(t, "feat-a", featA.Name)
+	require.Len(t, featA.Children, 1)
+	assert.Equal(t, "feat-b", featA.Children[0].Name)
+	assert.True(t, featA.Children[0].Recorded)
+}
+
+func TestLoad_Reflog(t *testing.T) {
+	path := createTestRepo(t)
+	repo := openRepo(t, path)
+
+	// feature was cut from develop, which moved on afterwards
+	createBranch(t, repo, "develop")
+	createBranch(t, repo, "feature")
+	checkoutBranch(t, repo, "develop")
+	commitFile(t, repo, "file1.txt", "content1")
+	checkoutBranch(t, repo, "feature")
+	commitFile(t, repo, "file2.txt", "content2")
+
+	reflog := filepath.Join(path, ".git", "logs", "refs", "heads", "feature")
+	require.NoError(t, os.MkdirAll(filepath.Dir(reflog), 0755))
+	entry := plumbing.ZeroHash.String() + " " + plumbing.ZeroHash.String() +
+		" Test User <test@example.com> 1700000000 +0000\tbranch: Created from develop\n"
+	require.NoError(t, os.WriteFile(reflog, []byte(entry), 0644))
+
+	r, err := git.Open(path)
+	require.NoError(t, err)
+
+	tr, err := Load(r, Options{Inference: InferReflog})
+	require.NoError(t, err)
+
+	require.Len(t, tr.Root.Children, 1)
+	master := tr.Root.Children[0]
+	require.Len(t, master.Children, 1)
+	develop := master.Children[0]
+	assert.Equal(t, "develop", develop.Name)
+	require.Len(t, develop.Children, 1)
+	feature := develop.Children[0]
+	assert.Equal(t, "feature", feature.Name)
+	assert.True(t, feature.IsCurrent)
+	assert.Equal(t, 1, feature.Ahead)
+	assert.Equal(t, 1, feature.Behind)
+}
+
+func TestLoad_Sort(t *testing.T) {
+	path := createTestRepo(t)
+	repo := openRepo(t, path)
+
+	for _, b := range []struct {
+		name    string
+		commits int
+	}{{"zeta", 2}, {"alpha", 1}, {"mid", 3}} {
+		checkoutBranch(t, repo, "master")
+		createBranch(t, repo, b.name)
+		checkoutBranch(t, repo, b.name)
+		for i := 0; i < b.commits; i++ {
+			commitFile(t, repo, b.name+".txt", strings.Repeat("x", i+1))
+		}
+	}
+	checkoutBranch(t, repo, "master")
+
+	// zeta was created before mid; alpha has no reflog
+	for name, seconds := range map[string]string{"zeta": "1700000000", "mid": "1700000100"} {
+		reflog := filepath.Join(path, ".git", "logs", "refs", "heads", name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(reflog), 0755))
+		entry := plumbing.ZeroHash.String() + " " + plumbing.ZeroHash.String() +
+			" Test User <test@example.com> " + seconds + " +0000\tbranch: Created from master\n"
+		require.NoError(t, os.WriteFile(reflog, []byte(entry), 0644))
+	}
+
+	r, err := git.Open(path)
+	require.NoError(t, err)
+
+	tests := []struct {
+		order tree.SortOrder
+		want  []string
+	}{
+		{tree.SortName, []string{"alpha", "mid", "zeta"}},
+		{tree.SortAhead, []string{"mid", "zeta", "alpha"}},
+		{tree.SortCreation, []string{"zeta", "mid", "alpha"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.order), func(t *testing.T) {
+			tr, err := Load(r, Options{Sort: tt.order})
+			require.NoError(t, err)
+
+			require.Len(t, tr.Root.Children, 1)
+			var got []string
+			for _, child := range tr.Root.Children[0].Children {
+				got = append(got, child.Name)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	_, err = Load(r, Options{Sort: "size"})
+	assert.ErrorIs(t, err, tree.ErrUnknownSortOrder)
+}
+
+func TestFindTrunk(t *testing.T) {
+	branches := []git.Branch{{Name: "develop"}, {Name: "origin/main"}, {Name: "master"}}
+
+	trunk, err := FindTrunk(branches, "")
+	require.NoError(t, err)
+	assert.Equal(t, "master", trunk.Name)
+
+	trunk, err = FindTrunk(branches, "develop")
+	require.NoError(t, err)
+	assert.Equal(t, "develop", trunk.Name)
+
+	_, err = FindTrunk(branches[:1], "")
+	assert.ErrorIs(t, err, ErrTrunkNotFound)
+}
+
+func TestLoad_UnknownInference(t *testing.T) {
+	r, err := git.Open(createTestRepo(t))
+	require.NoError(t, err)
+
+	_, err = Load(r, Options{Inference: "magic"})
+	assert.ErrorIs(t, err, ErrUnknownInference)
+	assert.ErrorIs(t, Inference("magic").Validate(), ErrUnknownInference)
+	assert.NoError(t, InferForkPoint.Validate())
+}
+
+func TestLoad_NoBranches(t *testing.T) {
+	dir := t.TempDir()
+	_, err := gogit.PlainInit(dir, false)
+	require.NoError(t, err)
+
+	r, err := git.Open(dir)
+	require.NoError(t, err)
+
+	_, err = Load(r, Options{})
+	assert.ErrorIs(t, err, ErrNoBranches)
+}
+
+func createTestRepo(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	repo, err := gogit.PlainInit(dir, false)
+	require.NoError(t, err)
+
+	w, err := repo.Worktree()
+	require.NoError(t, err)
+
+	filename := filepath.Join(dir, "README.md")
+	err = os.WriteFile(filename, []byte("# Test"), 0644)
+	require.NoError(t, err)
+
+	_, err = w.Add("README.md")
+	require.NoError(t, err)
+
+	_, err = w.Commit("Initial commit", &gogit.CommitOptions{
+		Author: &object.Signature{
+			Name:  "Test User",
+			Email: "test@example.com",
+			When:  time.Now(),
+		},
+	})
+	require.NoError(t, err)
+
+	return dir
+}
+
+func openRepo(t *testing.T, path string) *gogit.Repository {
+	t.Helper()
+
+	repo, err := gogit.PlainOpen(path)
+	require.NoError(t, err)
+	return repo
+}
+
+func createBranch(t *testing.T, repo *gogit.Repository, name string) {
+	t.Helper()
+
+	head, err := repo.Head()
+	require.NoError(t, err)
+
+	ref := plumbing.NewHashReference(plumbing.NewBranchReferenceName(name), head.Hash())
+	err = repo.Storer.SetReference(ref)
+	require.NoError(t, err)
+}
+
+func checkoutBranch(t *testing.T, repo *gogit.Repository, name string) {
+	t.Helper()
+
+	w, err := repo.Worktree()
+	require.NoError(t, err)
+
+	err = w.Checkout(&gogit.CheckoutOptions{
+		Branch: plumbing.NewBranchReferenceName(name),
+	})
+	require.NoError(t, err)
+}
+
+func commitFile(t *testing.T, repo *gogit.Repository, filename, content string) {
+	t.Helper()
+
+	w, err := repo.Worktree()
+	require.NoError(t, err)
+
+	path := filepath.Join(w.Filesystem.Root(), filename)
+	err = os.WriteFile(path, []byte(content), 0644)
+	require.NoError(t, err)
+
+	_, err = w.Add(filename)
+	require.NoError(t, err)
+
+	_, err = w.Commit("Add "+filename, &gogit.CommitOptions{
+		Author: &object.Signature{
+			Name:  "Test User",
+			Email: "test@example.com",
+			When:  time.Now(),
+		},
+	})
+	require.NoError(t, err)
+}
+
+func branchHash(t *testing.T, repo *gogit.Repository, name string) plumbing.Hash {
+	t.Helper()
+
+	ref, err := repo.Reference(plumbing.NewBranchReferenceName(name), true)
+	require.NoError(t, err)
+	return ref.Hash()
+}
+
+type reflogLine struct {
+	hash    plumbing.Hash
+	message string
+}
+
+// writes the reflog of ref, oldest entry first
+func writeReflog(t *testing.T, path, ref string, lines ...reflogLine) {
+	t.Helper()
+
+	var content strings.Builder
+	for _, line := range lines {
+		fmt.Fprintf(&content, "%s %s Test User <test@example.com> 1700000000 +0000\t%s\n",
+			plumbing.ZeroHash, line.hash, line.message)
+	}
+
+	file := filepath.Join(path, ".git", "logs", filepath.FromSlash(ref))
+	require.NoError(t, os.MkdirAll(filepath.Dir(file), 0755))
+	require.NoError(t, os.WriteFile(file, []byte(content.String()), 0644))
+}
@@ -0,0 +1,309 @@
+package git
+
+import (
+	"bytes"
+	"container/heap"
+	"fmt"
+	"math"
+	"math/bits"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	commitgraphfmt "github.com/go-git/go-git/v5/plumbing/format/commitgraph/v2"
+	"github.com/go-git/go-git/v5/plumbing/object/commitgraph"
+	"github.com/go-git/go-git/v5/storage/filesystem"
+)
+
+// set of branch indexes
+type bitset []uint64
+
+func newBitset(size int) bitset {
+	return make(bitset, (size+63)/64)
+}
+
+func (b bitset) set(i int) {
+	b[i/64] |= 1 << (uint(i) % 64)
+}
+
+func (b bitset) has(i int) bool {
+	return b[i/64]&(1<<(uint(i)%64)) != 0
+}
+
+// adds all members of other to b and reports whether b changed
+func (b bitset) merge(other bitset) bool {
+	changed := false
+	for i, word := range other {
+		if b[i]|word != b[i] {
+			b[i] |= word
+			changed = true
+		}
+	}
+	return changed
+}
+
+func (b bitset) each(fn func(i int)) {
+	for w, word := range b {
+		for word != 0 {
+			fn(w*64 + bits.TrailingZeros64(word))
+			word &= word - 1
+		}
+	}
+}
+
+type walkEntry struct {
+	node       commitgraph.CommitNode
+	generation uint64
+	when       time.Time
+}
+
+func newWalkEntry(node commitgraph.CommitNode) walkEntry {
+	return walkEntry{
+		node:       node,
+		generation: node.Generation(),
+		when:       node.CommitTime(),
+	}
+}
+
+// reports whether e may still be a descendant of a commit at the given
+// generation and time. Generation numbers from the commit-graph file are
+// exact; commits outside of it fall back to comparing committer dates.
+func (e walkEntry) above(generation uint64, when time.Time) bool {
+	if e.generation != math.MaxUint64 {
+		return e.generation > generation
+	}
+	return !e.when.Before(when)
+}
+
+// Commits a walk ordered by committer date goes on for once every queued
+// commit is shared by all tips, so that a commit with a skewed clock below
+// them can still be reached, as git does.
+const walkSlop = 5
+
+// max-heap of commits ordered by generation, then committer date, newest
+// first; commits that tie on both are ordered by hash so that every walk
+// visits them in the same order
+type walkQueue []walkEntry
+
+func (q walkQueue) Len() int { return len(q) }
+func (q walkQueue) Less(i, j int) bool {
+	if q[i].generation != q[j].generation {
+		return q[i].generation > q[j].generation
+	}
+	if !q[i].when.Equal(q[j].when) {
+		return q[i].when.After(q[j].when)
+	}
+	a, b := q[i].node.ID(), q[j].node.ID()
+	return bytes.Compare(a[:], b[:]) < 0
+}
+func (q walkQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+func (q *walkQueue) Push(x any)   { *q = append(*q, x.(walkEntry)) }
+func (q *walkQueue) Pop() any {
+	old := *q
+	entry := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return entry
+}
+
+// state of a single walk over the union of several branch histories,
+// tracking for each visited commit which branch tips can reach it
+type historyWalk struct {
+	nodes  commitgraph.CommitNodeIndex
+	size   int
+	reach  map[plumbing.Hash]bitset
+	queued map[plumbing.Hash]bool
+	queue  walkQueue
+}
+
+// starts a walk at the tips of branches and returns the entries of the
+// distinct tip commits
+func (r *Repository) newHistoryWalk(branches []Branch) (*historyWalk, []walkEntry, error) {
+	w := &historyWalk{
+		nodes:  r.commitNodes(),
+		size:   len(branches),
+		reach:  make(map[plumbing.Hash]bitset),
+		queued: make(map[plumbing.Hash]bool),
+	}
+
+	var tips []walkEntry
+	for i, b := range branches {
+		entry, queued, err := w.addTip(i, b.Hash)
+		if err != nil {
+			return nil, nil, fmt.Errorf("getting commit for %s: %w", b.Name, err)
+		}
+		if queued {
+			tips = append(tips, entry)
+		}
+	}
+
+	return w, tips, nil
+}
+
+// marks hash as reached by the i-th branch and queues it, unless it already
+// is; returns the entry and whether it was queued now
+func (w *historyWalk) addTip(i int, hash plumbing.Hash) (walkEntry, bool, error) {
+	if w.queued[hash] {
+		w.reachOf(hash).set(i)
+		return walkEntry{}, false, nil
+	}
+
+	node, err := w.nodes.Get(hash)
+	if err != nil {
+		return walkEntry{}, false, err
+	}
+
+	w.reachOf(hash).set(i)
+	entry := newWalkEntry(node)
+	w.queued[hash] = true
+	heap.Push(&w.queue, entry)
+	return entry, true, nil
+}
+
+func (w *historyWalk) reachOf(hash plumbing.Hash) bitset {
+	set, ok := w.reach[hash]
+	if !ok {
+		set = newBitset(w.size)
+		w.reach[hash] = set
+	}
+	return set
+}
+
+// reports whether every queued commit can be reached from all tips
+func (w *historyWalk) queueShared() bool {
+	for _, entry := range w.queue {
+		reach := w.reach[entry.node.ID()]
+		for i := 0; i < w.size; i++ {
+			if !reach.has(i) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (w *historyWalk) pop() walkEntry {
+	entry := heap.Pop(&w.queue).(walkEntry)
+	delete(w.queued, entry.node.ID())
+	return entry
+}
+
+// passes the tips reaching entry on to its parents, queueing every parent
+// whose set of reaching tips grew
+func (w *historyWalk) expand(entry walkEntry) error {
+	reach := w.reach[entry.node.ID()]
+
+	for _, parentHash := range entry.node.ParentHashes() {
+		if !w.reachOf(parentHash).merge(reach) || w.queued[parentHash] {
+			continue
+		}
+
+		parent, err := w.nodes.Get(parentHash)
+		if err != nil {
+			return fmt.Errorf("getting commit %s: %w", parentHash, err)
+		}
+
+		w.queued[parentHash] = true
+		heap.Push(&w.queue, newWalkEntry(parent))
+	}
+
+	return nil
+}
+
+// Walks the union of all branch histories once, newest commits first, and
+// returns for each visited commit the set of branches (by index) whose tip
+// can reach it. The walk stops as soon as every tip has been visited and the
+// remaining commits sit below the lowest tip, since nothing under that point
+// can change which tips reach which. Generation numbers from the commit-graph
+// file make that cut-off exact; without them committer dates are used, and
+// the walk goes on for walkSlop commits below the oldest tip.
+func (r *Repository) reachingTips(branches []Branch) (map[plumbing.Hash]bitset, error) {
+	w, tips, err := r.newHistoryWalk(branches)
+	if err != nil {
+		return nil, err
+	}
+
+	pendingTips := make(map[plumbing.Hash]bool)
+	lowestGeneration := uint64(math.MaxUint64)
+	var oldestTip time.Time
+	for _, tip := range tips {
+		pendingTips[tip.node.ID()] = true
+		lowestGeneration = min(lowestGeneration, tip.generation)
+		if oldestTip.IsZero() || tip.when.Before(oldestTip) {
+			oldestTip = tip.when
+		}
+	}
+
+	slop := walkSlop
+	for w.queue.Len() > 0 {
+		entry := w.pop()
+
+		if len(pendingTips) == 0 && !entry.above(lowestGeneration, oldestTip) {
+			// commits from the commit-graph are popped last and in generation
+			// order, so none of the remaining ones can reach a tip either
+			if entry.generation != math.MaxUint64 {
+				break
+			}
+			// a commit dated before the oldest tip may still lead up to one
+			// if its clock was skewed, so a few more are walked
+			slop--
+			if slop == 0 {
+				break
+			}
+		} else {
+			slop = walkSlop
+		}
+		delete(pendingTips, entry.node.ID())
+
+		if err := w.expand(entry); err != nil {
+			return nil, err
+		}
+	}
+
+	return w.reach, nil
+}
+
+// returns the set of commits reachable from hash, including hash itself
+func (r *Repository) ancestors(hash plumbing.Hash) (map[plumbing.Hash]bool, error) {
+	nodes := r.commitNodes()
+	seen := map[plumbing.Hash]bool{hash: true}
+	stack := []plumbing.Hash{hash}
+
+	for len(stack) > 0 {
+		current := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		node, err := nodes.Get(current)
+		if err != nil {
+			return nil, fmt.Errorf("getting commit %s: %w", current, err)
+		}
+
+		for _, parent := range node.ParentHashes() {
+			if !seen[parent] {
+				seen[parent] = true
+				stack = append(stack, parent)
+			}
+		}
+	}
+
+	return seen, nil
+}
+
+// Returns the commit index used for walking history. Repositories with a
+// commit-graph file (or split chain) get an index backed by it, which
+// provides parents and generation numbers without decoding commit objects.
+func (r *Repository) commitNodes() commitgraph.CommitNodeIndex {
+	if r.nodes == nil {
+		r.nodes = newCommitNodeIndex(r.repo)
+	}
+	return r.nodes
+}
+
+func newCommitNodeIndex(repo *git.Repository) commitgraph.CommitNodeIndex {
+	if fsStorage, ok := repo.Storer.(*filesystem.Storage); ok {
+		// a missing or unreadable commit-graph is not an error, just slower
+		if index, err := commitgraphfmt.OpenChainOrFileIndex(fsStorage.Filesystem()); err == nil {
+			return commitgraph.NewGraphCommitNodeIndex(index, repo.Storer)
+		}
+	}
+	return commitgraph.NewObjectCommitNodeIndex(repo.Storer)
+}
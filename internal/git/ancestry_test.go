@@ -0,0 +1,376 @@
+package git
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	commitgraphfmt "github.com/go-git/go-git/v5/plumbing/format/commitgraph/v2"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/object/commitgraph"
+	"github.com/go-git/go-git/v5/storage/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBitset(t *testing.T) {
+	a := newBitset(130)
+	a.set(0)
+	a.set(64)
+	a.set(129)
+
+	assert.True(t, a.has(64))
+	assert.False(t, a.has(65))
+
+	b := newBitset(130)
+	b.set(1)
+	assert.True(t, b.merge(a))
+	assert.False(t, b.merge(a))
+
+	var members []int
+	b.each(func(i int) { members = append(members, i) })
+	assert.Equal(t, []int{0, 1, 64, 129}, members)
+}
+
+func TestGetBranchRelationships_MatchesPairwise(t *testing.T) {
+	repo, branches := createSyntheticRepo(t, 300, 60, 5)
+
+	got, err := repo.GetBranchRelationships(branches)
+	require.NoError(t, err)
+
+	want, err := pairwiseRelationships(branches)
+	require.NoError(t, err)
+
+	assert.Equal(t, want, got)
+}
+
+func TestGetBranchRelationships_CommitGraph(t *testing.T) {
+	path := createTestRepo(t)
+	gitRepo := openGitRepo(t, path)
+
+	createBranch(t, gitRepo, "feature")
+	checkoutBranch(t, gitRepo, "feature")
+	commitFile(t, gitRepo, "file1.txt", "content1")
+	writeCommitGraph(t, gitRepo, path)
+
+	// commits created after the commit-graph was written are only available
+	// as objects, so the walk has to cross from one to the other
+	createBranch(t, gitRepo, "bugfix")
+	checkoutBranch(t, gitRepo, "bugfix")
+	commitFile(t, gitRepo, "file2.txt", "content2")
+
+	repo, err := Open(path)
+	require.NoError(t, err)
+
+	branches, err := repo.GetBranches()
+	require.NoError(t, err)
+
+	generations := make(map[string]uint64)
+	for _, b := range branches {
+		node, err := repo.commitNodes().Get(b.Hash)
+		require.NoError(t, err)
+		generations[b.Name] = node.Generation()
+	}
+	assert.Equal(t, uint64(1), generations["master"])
+	assert.Equal(t, uint64(2), generations["feature"])
+	assert.Equal(t, uint64(math.MaxUint64), generations["bugfix"])
+
+	rel, err := repo.GetBranchRelationships(branches)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]map[string]bool{
+		"master":  {"feature": true, "bugfix": true},
+		"feature": {"bugfix": true},
+		"bugfix":  {},
+	}, rel)
+}
+
+func TestGetBranchRelationships_ClockSkew(t *testing.T) {
+	t.Run("commit-graph", func(t *testing.T) {
+		testClockSkew(t, true)
+	})
+	t.Run("dates", func(t *testing.T) {
+		testClockSkew(t, false)
+	})
+}
+
+func testClockSkew(t *testing.T, withCommitGraph bool) {
+	gitRepo, err := git.Init(memory.NewStorage(), nil)
+	require.NoError(t, err)
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	base := storeCommit(t, gitRepo, "base", now)
+	main := storeCommit(t, gitRepo, "main", now.Add(time.Hour), base)
+	// a commit whose clock was far behind sits between main and feature
+	skewed := storeCommit(t, gitRepo, "skewed", now.Add(-24*time.Hour), main)
+	feature := storeCommit(t, gitRepo, "feature", now.Add(30*time.Minute), skewed)
+
+	setBranchRef(t, gitRepo, "main", main)
+	setBranchRef(t, gitRepo, "feature", feature)
+
+	repo := &Repository{repo: gitRepo}
+	if withCommitGraph {
+		repo.nodes = commitgraph.NewGraphCommitNodeIndex(buildCommitGraph(t, gitRepo), gitRepo.Storer)
+	}
+
+	branches, err := repo.GetBranches()
+	require.NoError(t, err)
+
+	rel, err := repo.GetBranchRelationships(branches)
+	require.NoError(t, err)
+	assert.True(t, rel["main"]["feature"])
+	assert.False(t, rel["feature"]["main"])
+}
+
+func TestAheadBehind_MatchesFullHistory(t *testing.T) {
+	repo, branches := createSyntheticRepo(t, 300, 30, 5)
+	var main Branch
+	for _, b := range branches {
+		if b.Name == "main" {
+			main = b
+		}
+	}
+
+	check := func(t *testing.T) {
+		for _, b := range branches {
+			ahead, behind, err := repo.AheadBehind(main, b)
+			require.NoError(t, err)
+
+			wantAhead, wantBehind, err := fullAheadBehind(repo, main, b)
+			require.NoError(t, err)
+			assert.Equal(t, wantAhead, ahead, b.Name)
+			assert.Equal(t, wantBehind, behind, b.Name)
+		}
+	}
+
+	t.Run("dates", check)
+
+	repo.nodes = commitgraph.NewGraphCommitNodeIndex(buildCommitGraph(t, repo.repo), repo.repo.Storer)
+	t.Run("commit-graph", check)
+}
+
+func TestAheadBehind_ClockSkew(t *testing.T) {
+	gitRepo, err := git.Init(memory.NewStorage(), nil)
+	require.NoError(t, err)
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	base := storeCommit(t, gitRepo, "base", now)
+	main := storeCommit(t, gitRepo, "main", now.Add(time.Hour), base)
+	skewed := storeCommit(t, gitRepo, "skewed", now.Add(-24*time.Hour), main)
+	feature := storeCommit(t, gitRepo, "feature", now.Add(30*time.Minute), skewed)
+
+	repo := &Repository{repo: gitRepo}
+	ahead, behind, err := repo.AheadBehind(Branch{Name: "main", Hash: main}, Branch{Name: "feature", Hash: feature})
+	require.NoError(t, err)
+	assert.Equal(t, 2, ahead)
+	assert.Equal(t, 0, behind)
+}
+
+func BenchmarkGetBranchRelationships(b *testing.B) {
+	for _, numBranches := range []int{25, 50, 100, 250, 500} {
+		repo, branches := createSyntheticRepo(b, 1000, numBranches, 5)
+
+		b.Run(fmt.Sprintf("walk/branches=%d", numBranches), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := repo.GetBranchRelationships(branches); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+
+		// the quadratic reference takes minutes beyond this
+		if numBranches > 100 {
+			continue
+		}
+		b.Run(fmt.Sprintf("pairwise/branches=%d", numBranches), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := pairwiseRelationships(branches); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// the original O(n²) IsAncestor implementation, kept as a reference
+func pairwiseRelationships(branches []Branch) (map[string]map[string]bool, error) {
+	relationships := make(map[string]map[string]bool)
+	for _, b := range branches {
+		relationships[b.Name] = make(map[string]bool)
+	}
+
+	for i := range branches {
+		for j := range branches {
+			if i == j || branches[i].Hash == branches[j].Hash {
+				continue
+			}
+			isAncestor, err := branches[i].Commit.IsAncestor(branches[j].Commit)
+			if err != nil {
+				return nil, err
+			}
+			if isAncestor {
+				relationships[branches[i].Name][branches[j].Name] = true
+			}
+		}
+	}
+
+	return relationships, nil
+}
+
+// counts ahead/behind from the complete histories of both branches
+func fullAheadBehind(repo *Repository, base, tip Branch) (ahead, behind int, err error) {
+	baseAncestors, err := repo.ancestors(base.Hash)
+	if err != nil {
+		return 0, 0, err
+	}
+	tipAncestors, err := repo.ancestors(tip.Hash)
+	if err != nil {
+		return 0, 0, err
+	}
+	for hash := range tipAncestors {
+		if !baseAncestors[hash] {
+			ahead++
+		}
+	}
+	for hash := range baseAncestors {
+		if !tipAncestors[hash] {
+			behind++
+		}
+	}
+	return ahead, behind, nil
+}
+
+// Builds an in-memory repository with a trunk of trunkLen commits and
+// numBranches branches of branchLen commits each. Branches fork either from
+// a random trunk commit or from the tip of an earlier branch, which yields
+// stacks of dependent branches.
+func createSyntheticRepo(tb testing.TB, trunkLen, numBranches, branchLen int) (*Repository, []Branch) {
+	tb.Helper()
+
+	gitRepo, err := git.Init(memory.NewStorage(), nil)
+	require.NoError(tb, err)
+
+	rng := rand.New(rand.NewSource(1))
+	clock := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	commit := func(msg string, parents ...plumbing.Hash) plumbing.Hash {
+		clock = clock.Add(time.Minute)
+		return storeCommit(tb, gitRepo, msg, clock, parents...)
+	}
+
+	trunk := []plumbing.Hash{commit("trunk 0")}
+	var tips []plumbing.Hash
+
+	for i := 0; i < numBranches; i++ {
+		// interleave trunk progress with branch creation
+		for j := 0; j < trunkLen/numBranches; j++ {
+			trunk = append(trunk, commit(fmt.Sprintf("trunk %d", len(trunk)), trunk[len(trunk)-1]))
+		}
+
+		base := trunk[rng.Intn(len(trunk))]
+		if len(tips) > 0 && rng.Intn(3) == 0 {
+			base = tips[rng.Intn(len(tips))]
+		}
+
+		tip := base
+		for j := 0; j < branchLen; j++ {
+			tip = commit(fmt.Sprintf("branch %d commit %d", i, j), tip)
+		}
+		tips = append(tips, tip)
+		setBranchRef(tb, gitRepo, fmt.Sprintf("branch-%03d", i), tip)
+	}
+	setBranchRef(tb, gitRepo, "main", trunk[len(trunk)-1])
+
+	repo := &Repository{repo: gitRepo}
+	branches, err := repo.GetBranches()
+	require.NoError(tb, err)
+
+	return repo, branches
+}
+
+func storeCommit(tb testing.TB, repo *git.Repository, msg string, when time.Time, parents ...plumbing.Hash) plumbing.Hash {
+	tb.Helper()
+
+	treeObj := repo.Storer.NewEncodedObject()
+	require.NoError(tb, (&object.Tree{}).Encode(treeObj))
+	treeHash, err := repo.Storer.SetEncodedObject(treeObj)
+	require.NoError(tb, err)
+
+	sig := object.Signature{Name: "Test User", Email: "test@example.com", When: when}
+	c := &object.Commit{
+		Author:       sig,
+		Committer:    sig,
+		Message:      msg,
+		TreeHash:     treeHash,
+		ParentHashes: parents,
+	}
+	obj := repo.Storer.NewEncodedObject()
+	require.NoError(tb, c.Encode(obj))
+	hash, err := repo.Storer.SetEncodedObject(obj)
+	require.NoError(tb, err)
+	return hash
+}
+
+func setBranchRef(tb testing.TB, repo *git.Repository, name string, hash plumbing.Hash) {
+	tb.Helper()
+
+	ref := plumbing.NewHashReference(plumbing.NewBranchReferenceName(name), hash)
+	require.NoError(tb, repo.Storer.SetReference(ref))
+}
+
+// builds an in-memory commit-graph covering every commit in the repository
+func buildCommitGraph(tb testing.TB, repo *git.Repository) *commitgraphfmt.MemoryIndex {
+	tb.Helper()
+
+	commits := make(map[plumbing.Hash]*object.Commit)
+	iter, err := repo.CommitObjects()
+	require.NoError(tb, err)
+	require.NoError(tb, iter.ForEach(func(c *object.Commit) error {
+		commits[c.Hash] = c
+		return nil
+	}))
+
+	generations := make(map[plumbing.Hash]uint64)
+	var generation func(hash plumbing.Hash) uint64
+	generation = func(hash plumbing.Hash) uint64 {
+		if g, ok := generations[hash]; ok {
+			return g
+		}
+		g := uint64(1)
+		for _, parent := range commits[hash].ParentHashes {
+			g = max(g, generation(parent)+1)
+		}
+		generations[hash] = g
+		return g
+	}
+
+	index := commitgraphfmt.NewMemoryIndex()
+	for hash, c := range commits {
+		index.Add(hash, &commitgraphfmt.CommitData{
+			TreeHash:     c.TreeHash,
+			ParentHashes: c.ParentHashes,
+			Generation:   generation(hash),
+			When:         c.Committer.When,
+		})
+	}
+	return index
+}
+
+// writes a commit-graph file the way `git commit-graph write` would
+func writeCommitGraph(tb testing.TB, repo *git.Repository, path string) {
+	tb.Helper()
+
+	dir := filepath.Join(path, ".git", "objects", "info")
+	require.NoError(tb, os.MkdirAll(dir, 0755))
+
+	f, err := os.Create(filepath.Join(dir, "commit-graph"))
+	require.NoError(tb, err)
+	defer f.Close()
+
+	require.NoError(tb, commitgraphfmt.NewEncoder(f).Encode(buildCommitGraph(tb, repo)))
+}
@@ -0,0 +1,196 @@
+package git
+
+import (
+	"container/heap"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// ForkPoint is the most recent commit a branch shares with another branch.
+type ForkPoint struct {
+	// the other branch
+	Branch string
+	// the merge-base of the two branches
+	Hash plumbing.Hash
+	// position of the merge-base in the walk, lower is more recent
+	Rank int
+}
+
+// Returns for every branch its fork points with all other branches whose
+// history it shares, most recent first and by name among equals. Branches
+// with unrelated histories have no fork point with each other.
+//
+// The commits in formerTips count as tips of their branch too, like the
+// reflog entries git merge-base --fork-point looks at, so a branch built on
+// a commit its parent amended or rebased away still forks off the parent
+// there. Former tips that no longer exist are skipped.
+func (r *Repository) GetForkPoints(branches []Branch, formerTips map[string]map[plumbing.Hash]bool) (map[string][]ForkPoint, error) {
+	w, _, err := r.newHistoryWalk(branches)
+	if err != nil {
+		return nil, err
+	}
+	for i, b := range branches {
+		for hash := range formerTips[b.Name] {
+			_, _, err := w.addTip(i, hash)
+			if err != nil && !errors.Is(err, plumbing.ErrObjectNotFound) {
+				return nil, fmt.Errorf("getting former tip of %s: %w", b.Name, err)
+			}
+		}
+	}
+
+	// The sets of reaching tips are only final once the walk is done: a
+	// commit ordered by date alone may be visited before one of its
+	// descendants and reached again later.
+	visited := make(map[plumbing.Hash]walkEntry)
+	met := make([]bitset, len(branches))
+	for i := range branches {
+		met[i] = newBitset(len(branches))
+	}
+	slop := walkSlop
+	for w.queue.Len() > 0 {
+		entry := w.pop()
+		visited[entry.node.ID()] = entry
+
+		reach := w.reach[entry.node.ID()]
+		reach.each(func(i int) { met[i].merge(reach) })
+
+		if err := w.expand(entry); err != nil {
+			return nil, err
+		}
+
+		// Commits further down are only reached by the tips reaching the
+		// queued ones, so once all of those have met there is nothing left
+		// to find. Generation numbers make that exact; commits ordered by
+		// date alone are walked a little further in case of a skewed clock.
+		if !w.allMet(met) {
+			slop = walkSlop
+			continue
+		}
+		if entry.generation != math.MaxUint64 {
+			break
+		}
+		slop--
+		if slop == 0 {
+			break
+		}
+	}
+
+	forkPoints := make(map[string][]ForkPoint, len(branches))
+	found := make([]bitset, len(branches))
+	for i := range branches {
+		found[i] = newBitset(len(branches))
+		found[i].set(i)
+	}
+
+	// the first commit shared by two branches is their merge-base, so a
+	// set of reaching tips that has already been seen yields nothing new
+	seenSets := make(map[string]bool)
+	rank := 0
+
+	for _, entry := range topoOrder(visited) {
+		reach := w.reach[entry.node.ID()]
+		key := reach.key()
+		if seenSets[key] {
+			continue
+		}
+		seenSets[key] = true
+
+		reach.each(func(i int) {
+			reach.each(func(j int) {
+				if found[i].has(j) {
+					return
+				}
+				found[i].set(j)
+				forkPoints[branches[i].Name] = append(forkPoints[branches[i].Name], ForkPoint{
+					Branch: branches[j].Name,
+					Hash:   entry.node.ID(),
+					Rank:   rank,
+				})
+			})
+		})
+		rank++
+	}
+
+	for _, points := range forkPoints {
+		sort.Slice(points, func(i, j int) bool {
+			if points[i].Rank != points[j].Rank {
+				return points[i].Rank < points[j].Rank
+			}
+			return points[i].Branch < points[j].Branch
+		})
+	}
+
+	return forkPoints, nil
+}
+
+// reports whether every two tips reaching a queued commit, be it the same
+// one or not, have already met at a visited commit
+func (w *historyWalk) allMet(met []bitset) bool {
+	pending := newBitset(w.size)
+	for _, entry := range w.queue {
+		pending.merge(w.reach[entry.node.ID()])
+	}
+
+	all := true
+	pending.each(func(i int) {
+		for k, word := range pending {
+			if met[i][k]&word != word {
+				all = false
+			}
+		}
+	})
+	return all
+}
+
+// Orders the visited commits so that every commit comes after all of its
+// visited descendants, picking the newest commit first among those that
+// are ready.
+func topoOrder(visited map[plumbing.Hash]walkEntry) []walkEntry {
+	children := make(map[plumbing.Hash]int, len(visited))
+	for _, entry := range visited {
+		for _, parent := range entry.node.ParentHashes() {
+			if _, ok := visited[parent]; ok {
+				children[parent]++
+			}
+		}
+	}
+
+	var ready walkQueue
+	for hash, entry := range visited {
+		if children[hash] == 0 {
+			ready = append(ready, entry)
+		}
+	}
+	heap.Init(&ready)
+
+	order := make([]walkEntry, 0, len(visited))
+	for ready.Len() > 0 {
+		entry := heap.Pop(&ready).(walkEntry)
+		order = append(order, entry)
+
+		for _, parent := range entry.node.ParentHashes() {
+			if _, ok := visited[parent]; !ok {
+				continue
+			}
+			children[parent]--
+			if children[parent] == 0 {
+				heap.Push(&ready, visited[parent])
+			}
+		}
+	}
+	return order
+}
+
+// returns the members of b as a comparable value
+func (b bitset) key() string {
+	buf := make([]byte, 0, len(b)*8)
+	for _, word := range b {
+		buf = binary.LittleEndian.AppendUint64(buf, word)
+	}
+	return string(buf)
+}
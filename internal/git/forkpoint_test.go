@@ -0,0 +1,216 @@
+package git
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/storage/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetForkPoints(t *testing.T) {
+	gitRepo, err := git.Init(memory.NewStorage(), nil)
+	require.NoError(t, err)
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(minutes int) time.Time { return now.Add(time.Duration(minutes) * time.Minute) }
+
+	m1 := storeCommit(t, gitRepo, "m1", at(1))
+	m2 := storeCommit(t, gitRepo, "m2", at(2), m1)
+	m3 := storeCommit(t, gitRepo, "m3", at(3), m2)
+	m4 := storeCommit(t, gitRepo, "m4", at(6), m3)
+	a1 := storeCommit(t, gitRepo, "a1", at(4), m2)
+	b1 := storeCommit(t, gitRepo, "b1", at(5), a1)
+	orphan := storeCommit(t, gitRepo, "orphan", at(7))
+
+	setBranchRef(t, gitRepo, "main", m4)
+	setBranchRef(t, gitRepo, "feat-a", a1)
+	setBranchRef(t, gitRepo, "feat-b", b1)
+	setBranchRef(t, gitRepo, "orphan", orphan)
+
+	repo := &Repository{repo: gitRepo}
+	branches, err := repo.GetBranches()
+	require.NoError(t, err)
+
+	forkPoints, err := repo.GetForkPoints(branches, nil)
+	require.NoError(t, err)
+
+	bases := func(branch string) map[string]plumbing.Hash {
+		got := make(map[string]plumbing.Hash)
+		for _, fp := range forkPoints[branch] {
+			got[fp.Branch] = fp.Hash
+		}
+		return got
+	}
+
+	assert.Equal(t, map[string]plumbing.Hash{"feat-a": m2, "feat-b": m2}, bases("main"))
+	assert.Equal(t, map[string]plumbing.Hash{"main": m2, "feat-b": a1}, bases("feat-a"))
+	assert.Equal(t, map[string]plumbing.Hash{"main": m2, "feat-a": a1}, bases("feat-b"))
+	assert.Empty(t, forkPoints["orphan"])
+
+	// most recent fork point first
+	require.Len(t, forkPoints["feat-b"], 2)
+	assert.Equal(t, "feat-a", forkPoints["feat-b"][0].Branch)
+	assert.Less(t, forkPoints["feat-b"][0].Rank, forkPoints["feat-b"][1].Rank)
+}
+
+func TestGetForkPoints_EqualTimestamps(t *testing.T) {
+	gitRepo, err := git.Init(memory.NewStorage(), nil)
+	require.NoError(t, err)
+
+	// every commit shares one timestamp, so only the hash orders the walk
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	m1 := storeCommit(t, gitRepo, "m1", now)
+	m2 := storeCommit(t, gitRepo, "m2", now, m1)
+	m3 := storeCommit(t, gitRepo, "m3", now, m2)
+	a1 := storeCommit(t, gitRepo, "a1", now, m2)
+	a2 := storeCommit(t, gitRepo, "a2", now, a1)
+	b1 := storeCommit(t, gitRepo, "b1", now, a2)
+	c1 := storeCommit(t, gitRepo, "c1", now, b1)
+
+	setBranchRef(t, gitRepo, "main", m3)
+	setBranchRef(t, gitRepo, "feat-a", a2)
+	setBranchRef(t, gitRepo, "feat-b", b1)
+	setBranchRef(t, gitRepo, "feat-c", c1)
+
+	repo := &Repository{repo: gitRepo}
+
+	var first map[string][]ForkPoint
+	for i := 0; i < 20; i++ {
+		branches, err := repo.GetBranches()
+		require.NoError(t, err)
+
+		forkPoints, err := repo.GetForkPoints(branches, nil)
+		require.NoError(t, err)
+
+		if first == nil {
+			first = forkPoints
+			continue
+		}
+		require.Equal(t, first, forkPoints)
+	}
+
+	// a descendant always ranks before its ancestors
+	branches := func(points []ForkPoint) []string {
+		var names []string
+		for _, fp := range points {
+			names = append(names, fp.Branch)
+		}
+		return names
+	}
+	assert.Equal(t, []string{"feat-b", "feat-a", "main"}, branches(first["feat-c"]))
+	assert.Equal(t, b1, first["feat-c"][0].Hash)
+	assert.Equal(t, a2, first["feat-c"][1].Hash)
+	assert.Equal(t, m2, first["feat-c"][2].Hash)
+}
+
+func TestGetForkPoints_ClockSkew(t *testing.T) {
+	gitRepo, err := git.Init(memory.NewStorage(), nil)
+	require.NoError(t, err)
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	m1 := storeCommit(t, gitRepo, "m1", now)
+	m2 := storeCommit(t, gitRepo, "m2", now.Add(time.Hour), m1)
+	// a commit whose clock was far behind is visited after its ancestors
+	skewed := storeCommit(t, gitRepo, "skewed", now.Add(-24*time.Hour), m2)
+	a1 := storeCommit(t, gitRepo, "a1", now.Add(30*time.Minute), skewed)
+	b1 := storeCommit(t, gitRepo, "b1", now.Add(20*time.Minute), skewed)
+
+	setBranchRef(t, gitRepo, "main", m2)
+	setBranchRef(t, gitRepo, "feat-a", a1)
+	setBranchRef(t, gitRepo, "feat-b", b1)
+
+	repo := &Repository{repo: gitRepo}
+	branches, err := repo.GetBranches()
+	require.NoError(t, err)
+
+	forkPoints, err := repo.GetForkPoints(branches, nil)
+	require.NoError(t, err)
+
+	require.Len(t, forkPoints["feat-b"], 2)
+	assert.Equal(t, "feat-a", forkPoints["feat-b"][0].Branch)
+	assert.Equal(t, skewed, forkPoints["feat-b"][0].Hash)
+	assert.Equal(t, "main", forkPoints["feat-b"][1].Branch)
+	assert.Equal(t, m2, forkPoints["feat-b"][1].Hash)
+	assert.Less(t, forkPoints["feat-b"][0].Rank, forkPoints["feat-b"][1].Rank)
+}
+
+func TestGetForkPoints_FormerTips(t *testing.T) {
+	gitRepo, err := git.Init(memory.NewStorage(), nil)
+	require.NoError(t, err)
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(minutes int) time.Time { return now.Add(time.Duration(minutes) * time.Minute) }
+
+	m1 := storeCommit(t, gitRepo, "m1", at(1))
+	a1 := storeCommit(t, gitRepo, "a1", at(2), m1)
+	b1 := storeCommit(t, gitRepo, "b1", at(3), a1)
+	// feat-a amended a1 after feat-b was built on it
+	amended := storeCommit(t, gitRepo, "a1 amended", at(4), m1)
+
+	setBranchRef(t, gitRepo, "main", m1)
+	setBranchRef(t, gitRepo, "feat-a", amended)
+	setBranchRef(t, gitRepo, "feat-b", b1)
+
+	repo := &Repository{repo: gitRepo}
+	branches, err := repo.GetBranches()
+	require.NoError(t, err)
+
+	forkPoints, err := repo.GetForkPoints(branches, nil)
+	require.NoError(t, err)
+	require.Len(t, forkPoints["feat-b"], 2)
+	assert.Equal(t, m1, forkPoints["feat-b"][0].Hash)
+	assert.Equal(t, m1, forkPoints["feat-b"][1].Hash)
+
+	// the reflog still leads feat-a to a1; commits that are gone are skipped
+	formerTips := map[string]map[plumbing.Hash]bool{
+		"feat-a": {a1: true, amended: true, plumbing.NewHash("0123456789abcdef0123456789abcdef01234567"): true},
+	}
+	forkPoints, err = repo.GetForkPoints(branches, formerTips)
+	require.NoError(t, err)
+	require.Len(t, forkPoints["feat-b"], 2)
+	assert.Equal(t, "feat-a", forkPoints["feat-b"][0].Branch)
+	assert.Equal(t, a1, forkPoints["feat-b"][0].Hash)
+	assert.Equal(t, "main", forkPoints["feat-b"][1].Branch)
+	assert.Less(t, forkPoints["feat-b"][0].Rank, forkPoints["feat-b"][1].Rank)
+}
+
+func TestGetForkPoints_StopsBelowForkPoints(t *testing.T) {
+	gitRepo, err := git.Init(memory.NewStorage(), nil)
+	require.NoError(t, err)
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(minutes int) time.Time { return now.Add(time.Duration(minutes) * time.Minute) }
+
+	// the history below the fork points leads to a commit that is missing,
+	// as in a shallow clone, so walking down to it fails
+	missing := plumbing.NewHash("0123456789abcdef0123456789abcdef01234567")
+	below := missing
+	for i := 0; i < 20; i++ {
+		below = storeCommit(t, gitRepo, "old", at(i), below)
+	}
+	m1 := storeCommit(t, gitRepo, "m1", at(30), below)
+	m2 := storeCommit(t, gitRepo, "m2", at(31), m1)
+	a1 := storeCommit(t, gitRepo, "a1", at(32), m1)
+	b1 := storeCommit(t, gitRepo, "b1", at(33), a1)
+
+	setBranchRef(t, gitRepo, "main", m2)
+	setBranchRef(t, gitRepo, "feat-a", a1)
+	setBranchRef(t, gitRepo, "feat-b", b1)
+
+	repo := &Repository{repo: gitRepo}
+	branches, err := repo.GetBranches()
+	require.NoError(t, err)
+
+	forkPoints, err := repo.GetForkPoints(branches, nil)
+	require.NoError(t, err)
+
+	require.Len(t, forkPoints["feat-b"], 2)
+	assert.Equal(t, a1, forkPoints["feat-b"][0].Hash)
+	assert.Equal(t, m1, forkPoints["feat-b"][1].Hash)
+	require.Len(t, forkPoints["main"], 2)
+	assert.Equal(t, m1, forkPoints["main"][0].Hash)
+}
@@ -0,0 +1,26 @@
+package git
+
+import (
+	"fmt"
+)
+
+// Returns the branches whose tips are contained in the history of trunk.
+// Branches pointing at the trunk's tip itself have nothing to merge yet and
+// are not considered merged.
+func (r *Repository) GetMergedBranches(branches []Branch, trunk Branch) (map[string]bool, error) {
+	tips := append([]Branch{trunk}, branches...)
+
+	reach, err := r.reachingTips(tips)
+	if err != nil {
+		return nil, fmt.Errorf("walking history of %s: %w", trunk.Name, err)
+	}
+
+	merged := make(map[string]bool)
+	for _, b := range branches {
+		if b.Hash != trunk.Hash && reach[b.Hash].has(0) {
+			merged[b.Name] = true
+		}
+	}
+
+	return merged, nil
+}
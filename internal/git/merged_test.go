@@ -0,0 +1,65 @@
+package git
+
+import (
+	"testing"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetMergedBranches(t *testing.T) {
+	path := createTestRepo(t)
+	gitRepo := openGitRepo(t, path)
+
+	// merged: master has moved past it
+	createBranch(t, gitRepo, "merged")
+	commitFile(t, gitRepo, "file1.txt", "content1")
+
+	// at the trunk tip: nothing to merge yet
+	createBranch(t, gitRepo, "fresh")
+
+	// unmerged: has its own commit
+	createBranch(t, gitRepo, "feature")
+	checkoutBranch(t, gitRepo, "feature")
+	commitFile(t, gitRepo, "file2.txt", "content2")
+
+	repo := &Repository{repo: gitRepo}
+	branches, err := repo.GetBranches()
+	require.NoError(t, err)
+
+	var trunk Branch
+	for _, b := range branches {
+		if b.Name == "master" {
+			trunk = b
+		}
+	}
+
+	merged, err := repo.GetMergedBranches(branches, trunk)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]bool{"merged": true}, merged)
+}
+
+func TestDeleteBranch(t *testing.T) {
+	path := createTestRepo(t)
+	gitRepo := openGitRepo(t, path)
+	createBranch(t, gitRepo, "feature")
+	addRemote(t, gitRepo, "origin")
+	deleteBranch(t, gitRepo, "feature")
+
+	// recreate feature with upstream configuration
+	repo := &Repository{repo: gitRepo}
+	require.NoError(t, repo.Checkout("origin/feature"))
+	require.NoError(t, repo.Checkout("master"))
+
+	require.NoError(t, repo.DeleteBranch("feature"))
+
+	_, err := gitRepo.Reference(plumbing.NewBranchReferenceName("feature"), false)
+	assert.ErrorIs(t, err, plumbing.ErrReferenceNotFound)
+
+	cfg, err := gitRepo.Config()
+	require.NoError(t, err)
+	assert.NotContains(t, cfg.Branches, "feature")
+
+	assert.Error(t, repo.DeleteBranch("feature"))
+}
@@ -0,0 +1,84 @@
+package git
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/go-git/go-git/v5/config"
+	format "github.com/go-git/go-git/v5/plumbing/format/config"
+)
+
+const (
+	branchSection = "branch"
+	// branch.<name>.gittreeParent holds the parent recorded with set-parent
+	parentKey = "gittreeParent"
+)
+
+// GetBranchParents returns the parents recorded with SetBranchParent, keyed
+// by branch name.
+func (r *Repository) GetBranchParents() (map[string]string, error) {
+	return GittreeParents.Parents(r)
+}
+
+// SetBranchParent records parent as the parent of branch in the repository
+// config, as branch.<branch>.gittreeParent.
+func (r *Repository) SetBranchParent(branch, parent string) error {
+	return r.setBranchOption(branch, parentKey, parent)
+}
+
+// UnsetBranchParent removes the recorded parent of branch, if any.
+func (r *Repository) UnsetBranchParent(branch string) error {
+	return r.setBranchOption(branch, parentKey, "")
+}
+
+// returns the value of <section>.<name>.<key> for every subsection name
+// that has it
+func (r *Repository) getSubsectionOptions(section, key string) (map[string]string, error) {
+	cfg, err := r.repo.Config()
+	if err != nil {
+		return nil, fmt.Errorf("reading config: %w", err)
+	}
+
+	values := make(map[string]string)
+	for _, sub := range cfg.Raw.Section(section).Subsections {
+		if value := sub.Option(key); value != "" {
+			values[sub.Name] = value
+		}
+	}
+	return values, nil
+}
+
+// sets branch.<name>.<key>, or removes it when value is empty
+func (r *Repository) setBranchOption(branch, key, value string) error {
+	cfg, err := r.repo.Config()
+	if err != nil {
+		return fmt.Errorf("reading config: %w", err)
+	}
+
+	section := cfg.Raw.Section(branchSection)
+	if value != "" {
+		section.Subsection(branch).SetOption(key, value)
+	} else if section.HasSubsection(branch) {
+		sub := section.Subsection(branch)
+		sub.RemoveOption(key)
+		if len(sub.Options) == 0 {
+			section.RemoveSubsection(branch)
+		}
+	}
+
+	// go-git only writes back branch sections it knows about, so reparse the
+	// raw config to pick up a section that did not exist before
+	var buf bytes.Buffer
+	if err := format.NewEncoder(&buf).Encode(cfg.Raw); err != nil {
+		return fmt.Errorf("encoding config: %w", err)
+	}
+	updated := config.NewConfig()
+	if err := updated.Unmarshal(buf.Bytes()); err != nil {
+		return fmt.Errorf("parsing config: %w", err)
+	}
+
+	if err := r.repo.SetConfig(updated); err != nil {
+		return fmt.Errorf("writing config: %w", err)
+	}
+	return nil
+}
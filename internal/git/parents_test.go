@@ -0,0 +1,59 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBranchParents(t *testing.T) {
+	path := createTestRepo(t)
+	gitRepo := openGitRepo(t, path)
+	createBranch(t, gitRepo, "feat/x")
+	createBranch(t, gitRepo, "feat/y")
+
+	// existing branch configuration is kept
+	cfg, err := gitRepo.Config()
+	require.NoError(t, err)
+	cfg.Branches["feat/y"] = &config.Branch{
+		Name:   "feat/y",
+		Remote: "origin",
+		Merge:  plumbing.NewBranchReferenceName("feat/y"),
+	}
+	require.NoError(t, gitRepo.SetConfig(cfg))
+
+	repo := &Repository{repo: gitRepo}
+	require.NoError(t, repo.SetBranchParent("feat/x", "master"))
+	require.NoError(t, repo.SetBranchParent("feat/y", "feat/x"))
+
+	parents, err := repo.GetBranchParents()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"feat/x": "master", "feat/y": "feat/x"}, parents)
+
+	raw, err := os.ReadFile(filepath.Join(path, ".git", "config"))
+	require.NoError(t, err)
+	assert.Contains(t, string(raw), `[branch "feat/x"]`)
+	assert.Contains(t, string(raw), "gittreeParent = master")
+
+	cfg, err = gitRepo.Config()
+	require.NoError(t, err)
+	assert.Equal(t, "origin", cfg.Branches["feat/y"].Remote)
+
+	require.NoError(t, repo.UnsetBranchParent("feat/x"))
+	require.NoError(t, repo.UnsetBranchParent("feat/y"))
+	require.NoError(t, repo.UnsetBranchParent("master"))
+
+	parents, err = repo.GetBranchParents()
+	require.NoError(t, err)
+	assert.Empty(t, parents)
+
+	cfg, err = gitRepo.Config()
+	require.NoError(t, err)
+	assert.NotContains(t, cfg.Branches, "feat/x")
+	assert.Equal(t, "origin", cfg.Branches["feat/y"].Remote)
+}
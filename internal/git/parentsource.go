@@ -0,0 +1,95 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrInvalidKeyPattern is returned for a parent key pattern that is not of
+// the form section.*.key.
+var ErrInvalidKeyPattern = errors.New("invalid parent key pattern, want section.*.key")
+
+// name of the multi-valued config option listing extra parent key patterns
+const parentKeysOption = "parentKey"
+
+// ParentSource provides branch parents recorded by a tool, keyed by branch.
+type ParentSource interface {
+	Name() string
+	Parents(r *Repository) (map[string]string, error)
+}
+
+// KeyPattern reads parents stored in the repository config under
+// <Section>.<branch>.<Key>, one entry per branch.
+type KeyPattern struct {
+	Section string
+	Key     string
+}
+
+var (
+	// parents recorded with gittree set-parent
+	GittreeParents = KeyPattern{Section: branchSection, Key: parentKey}
+	// parents recorded by git-town
+	GitTownParents = KeyPattern{Section: "git-town-branch", Key: "parent"}
+)
+
+// ParseKeyPattern parses a pattern such as "git-town-branch.*.parent", where
+// the * stands for the branch name.
+func ParseKeyPattern(pattern string) (KeyPattern, error) {
+	section, rest, ok := strings.Cut(pattern, ".")
+	if !ok {
+		return KeyPattern{}, fmt.Errorf("%w: %q", ErrInvalidKeyPattern, pattern)
+	}
+	wildcard, key, ok := strings.Cut(rest, ".")
+	if !ok || wildcard != "*" || section == "" || key == "" || strings.Contains(key, ".") {
+		return KeyPattern{}, fmt.Errorf("%w: %q", ErrInvalidKeyPattern, pattern)
+	}
+	return KeyPattern{Section: section, Key: key}, nil
+}
+
+func (p KeyPattern) Name() string {
+	return p.Section + ".*." + p.Key
+}
+
+func (p KeyPattern) Parents(r *Repository) (map[string]string, error) {
+	return r.getSubsectionOptions(p.Section, p.Key)
+}
+
+// ParentSources returns the sources of recorded parents in order of
+// precedence: gittree's own, git-town's, then every pattern listed in the
+// gittree.parentKey config option.
+func (r *Repository) ParentSources() ([]ParentSource, error) {
+	sources := []ParentSource{GittreeParents, GitTownParents}
+
+	cfg, err := r.repo.Config()
+	if err != nil {
+		return nil, fmt.Errorf("reading config: %w", err)
+	}
+	for _, pattern := range cfg.Raw.Section("gittree").OptionAll(parentKeysOption) {
+		source, err := ParseKeyPattern(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("gittree.%s: %w", parentKeysOption, err)
+		}
+		sources = append(sources, source)
+	}
+
+	return sources, nil
+}
+
+// GetRecordedParents merges the parents of all sources. When sources
+// disagree about a branch, the one listed first wins.
+func (r *Repository) GetRecordedParents(sources []ParentSource) (map[string]string, error) {
+	parents := make(map[string]string)
+	for _, source := range sources {
+		found, err := source.Parents(r)
+		if err != nil {
+			return nil, fmt.Errorf("reading parents from %s: %w", source.Name(), err)
+		}
+		for branch, parent := range found {
+			if _, ok := parents[branch]; !ok {
+				parents[branch] = parent
+			}
+		}
+	}
+	return parents, nil
+}
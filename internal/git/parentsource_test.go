@@ -0,0 +1,97 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseKeyPattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    KeyPattern
+		wantErr bool
+	}{
+		{pattern: "git-town-branch.*.parent", want: KeyPattern{Section: "git-town-branch", Key: "parent"}},
+		{pattern: "branch.*.stackParent", want: KeyPattern{Section: "branch", Key: "stackParent"}},
+		{pattern: "branch.feat.parent", wantErr: true},
+		{pattern: "branch.*", wantErr: true},
+		{pattern: "*.parent", wantErr: true},
+		{pattern: ".*.parent", wantErr: true},
+		{pattern: "branch.*.a.b", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			got, err := ParseKeyPattern(tt.pattern)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidKeyPattern)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.pattern, got.Name())
+		})
+	}
+}
+
+func TestGetRecordedParents(t *testing.T) {
+	path := createTestRepo(t)
+	gitRepo := openGitRepo(t, path)
+	repo := &Repository{repo: gitRepo}
+
+	appendConfig(t, path, `
+[git-town-branch "feat/a"]
+	parent = master
+[git-town-branch "feat/b"]
+	parent = feat/a
+[branch "feat/b"]
+	stackParent = master
+[branch "feat/c"]
+	stackParent = feat/b
+[gittree]
+	parentKey = branch.*.stackParent
+`)
+	require.NoError(t, repo.SetBranchParent("feat/a", "develop"))
+
+	sources, err := repo.ParentSources()
+	require.NoError(t, err)
+
+	var names []string
+	for _, source := range sources {
+		names = append(names, source.Name())
+	}
+	assert.Equal(t, []string{"branch.*.gittreeParent", "git-town-branch.*.parent", "branch.*.stackParent"}, names)
+
+	parents, err := repo.GetRecordedParents(sources)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"feat/a": "develop",
+		"feat/b": "feat/a",
+		"feat/c": "feat/b",
+	}, parents)
+}
+
+func TestParentSources_InvalidPattern(t *testing.T) {
+	path := createTestRepo(t)
+	repo := &Repository{repo: openGitRepo(t, path)}
+
+	appendConfig(t, path, "[gittree]\n\tparentKey = parent\n")
+
+	_, err := repo.ParentSources()
+	assert.ErrorIs(t, err, ErrInvalidKeyPattern)
+}
+
+func appendConfig(t *testing.T, path, text string) {
+	t.Helper()
+
+	f, err := os.OpenFile(filepath.Join(path, ".git", "config"), os.O_APPEND|os.O_WRONLY, 0644)
+	require.NoError(t, err)
+	defer f.Close()
+
+	_, err = f.WriteString(text)
+	require.NoError(t, err)
+}
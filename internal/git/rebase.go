@@ -0,0 +1,157 @@
+package git
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/storage/filesystem"
+)
+
+// ErrRebaseConflict is returned when a rebase stops because its changes do
+// not apply cleanly. The rebase is left in progress for the user to resolve.
+var ErrRebaseConflict = errors.New("rebase stopped on a conflict")
+
+// go-git has no rebase support, so rebases are delegated to the git binary.
+// These helpers are the only place gittree shells out.
+
+// Rebases the commits of branch that are not in upstream onto onto, as
+// git rebase --onto onto upstream branch. Leaves branch checked out.
+func (r *Repository) Rebase(onto, upstream plumbing.Hash, branch string) error {
+	_, err := r.runGit("rebase", "--onto", onto.String(), upstream.String(), branch)
+	if err != nil && r.RebaseInProgress() {
+		return fmt.Errorf("rebasing %s: %w", branch, ErrRebaseConflict)
+	}
+	if err != nil {
+		return fmt.Errorf("rebasing %s: %w", branch, err)
+	}
+	return nil
+}
+
+// Continues a rebase stopped on a conflict once the user has resolved it.
+func (r *Repository) ContinueRebase() error {
+	_, err := r.runGit("rebase", "--continue")
+	if err != nil && r.RebaseInProgress() {
+		return fmt.Errorf("continuing rebase: %w", ErrRebaseConflict)
+	}
+	if err != nil {
+		return fmt.Errorf("continuing rebase: %w", err)
+	}
+	return nil
+}
+
+// Aborts the rebase in progress, restoring the branch being rebased.
+func (r *Repository) AbortRebase() error {
+	if _, err := r.runGit("rebase", "--abort"); err != nil {
+		return fmt.Errorf("aborting rebase: %w", err)
+	}
+	return nil
+}
+
+// RebaseInProgress reports whether a rebase is stopped in the repository.
+func (r *Repository) RebaseInProgress() bool {
+	gitDir, err := r.GitDir()
+	if err != nil {
+		return false
+	}
+	for _, dir := range []string{"rebase-merge", "rebase-apply"} {
+		if _, err := os.Stat(filepath.Join(gitDir, dir)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// HasLocalChanges reports whether tracked files in the worktree or the index
+// differ from HEAD, which would stop a rebase before it starts. Untracked
+// files are left out, as git only minds those the rebase would overwrite.
+func (r *Repository) HasLocalChanges() (bool, error) {
+	out, err := r.runGit("status", "--porcelain", "--untracked-files=no")
+	if err != nil {
+		return false, err
+	}
+	return strings.TrimSpace(out) != "", nil
+}
+
+// Returns the commit branch was most likely created from on parent. It uses
+// the reflog of parent, like git merge-base --fork-point, so commits that
+// were amended or rebased away on parent are still recognized, and falls
+// back to the plain merge-base.
+func (r *Repository) ForkBase(parent, branch Branch) (plumbing.Hash, error) {
+	out, err := r.runGit("merge-base", "--fork-point", parent.Name, branch.Name)
+	if err == nil {
+		return plumbing.NewHash(strings.TrimSpace(out)), nil
+	}
+
+	bases, err := parent.Commit.MergeBase(branch.Commit)
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("finding merge-base of %s and %s: %w", parent.Name, branch.Name, err)
+	}
+	if len(bases) == 0 {
+		return plumbing.ZeroHash, fmt.Errorf("%s and %s have no common history", parent.Name, branch.Name)
+	}
+	return bases[0].Hash, nil
+}
+
+// IsAncestor reports whether ancestor is reachable from descendant.
+func (r *Repository) IsAncestor(ancestor, descendant plumbing.Hash) (bool, error) {
+	reachable, err := r.ancestors(descendant)
+	if err != nil {
+		return false, err
+	}
+	return reachable[ancestor], nil
+}
+
+// Points a local branch at hash, as git branch --force does. The branch
+// must not be checked out.
+func (r *Repository) ResetBranch(name string, hash plumbing.Hash) error {
+	if _, err := r.runGit("branch", "--force", name, hash.String()); err != nil {
+		return fmt.Errorf("resetting %s: %w", name, err)
+	}
+	return nil
+}
+
+// Detaches HEAD at its current commit, leaving every branch free to move.
+func (r *Repository) DetachHead() error {
+	if _, err := r.runGit("checkout", "--quiet", "--detach"); err != nil {
+		return fmt.Errorf("detaching HEAD: %w", err)
+	}
+	return nil
+}
+
+// GitDir returns the path of the repository's .git directory.
+func (r *Repository) GitDir() (string, error) {
+	fsStorage, ok := r.repo.Storer.(*filesystem.Storage)
+	if !ok {
+		return "", errors.New("repository is not stored on disk")
+	}
+	return fsStorage.Filesystem().Root(), nil
+}
+
+func (r *Repository) runGit(args ...string) (string, error) {
+	w, err := r.repo.Worktree()
+	if err != nil {
+		return "", fmt.Errorf("getting worktree: %w", err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("git", args...)
+	cmd.Dir = w.Filesystem.Root()
+	// never wait on an editor for commit messages
+	cmd.Env = append(os.Environ(), "GIT_EDITOR=true")
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return "", fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return stdout.String(), nil
+}
@@ -0,0 +1,249 @@
+package git
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+const (
+	// message git writes as the first reflog entry of a new branch
+	createdFromPrefix = "branch: Created from "
+	// message git writes to the HEAD reflog when switching branches
+	checkoutPrefix = "checkout: moving from "
+)
+
+// a single line of a reflog
+type reflogEntry struct {
+	newHash string
+	when    time.Time
+	message string
+}
+
+// GetBranchSources returns for every local branch the branch it was created
+// from, according to the "branch: Created from <source>" entry git writes
+// to the branch's reflog. Branches created from HEAD (git checkout -b, git
+// switch -c) are resolved to the branch HEAD was on, using the HEAD reflog.
+// Branches whose reflog is missing, was expired, or names a plain commit
+// are left out.
+func (r *Repository) GetBranchSources() (map[string]string, error) {
+	gitDir, err := r.GitDir()
+	if err != nil {
+		return nil, err
+	}
+
+	branches, err := r.GetBranches()
+	if err != nil {
+		return nil, err
+	}
+
+	headLog, err := readReflog(filepath.Join(gitDir, "logs", "HEAD"))
+	if err != nil {
+		return nil, err
+	}
+
+	sources := make(map[string]string)
+	for _, b := range branches {
+		entries, err := readReflog(filepath.Join(gitDir, "logs", filepath.FromSlash(refPrefix+b.Name)))
+		if err != nil {
+			return nil, err
+		}
+		if len(entries) == 0 || !strings.HasPrefix(entries[0].message, createdFromPrefix) {
+			continue
+		}
+
+		source := strings.TrimPrefix(entries[0].message, createdFromPrefix)
+		if source == "HEAD" {
+			source = checkedOutFrom(headLog, b.Name, entries[0].newHash)
+		}
+		source = normalizeSourceName(source)
+
+		if source != "" && source != b.Name {
+			sources[b.Name] = source
+		}
+	}
+
+	return sources, nil
+}
+
+// GetBranchCreationTimes returns for every local branch the time it was
+// created, taken from the "branch: Created from" entry of its reflog.
+// Branches without that entry are left out.
+func (r *Repository) GetBranchCreationTimes() (map[string]time.Time, error) {
+	gitDir, err := r.GitDir()
+	if err != nil {
+		return nil, err
+	}
+
+	branches, err := r.GetBranches()
+	if err != nil {
+		return nil, err
+	}
+
+	created := make(map[string]time.Time)
+	for _, b := range branches {
+		entries, err := readReflog(filepath.Join(gitDir, "logs", filepath.FromSlash(refPrefix+b.Name)))
+		if err != nil {
+			return nil, err
+		}
+		if len(entries) == 0 || !strings.HasPrefix(entries[0].message, createdFromPrefix) || entries[0].when.IsZero() {
+			continue
+		}
+		created[b.Name] = entries[0].when
+	}
+
+	return created, nil
+}
+
+// GetFormerTips returns for every local and remote-tracking branch the
+// commits its reflog shows it moving to by committing, resetting, merging,
+// rebasing, fetching or pushing, which leaves out the commit it was created
+// at. Branches without a reflog are left out.
+func (r *Repository) GetFormerTips() (map[string]map[plumbing.Hash]bool, error) {
+	gitDir, err := r.GitDir()
+	if err != nil {
+		return nil, err
+	}
+
+	local, err := r.GetBranches()
+	if err != nil {
+		return nil, err
+	}
+	remote, err := r.GetRemoteBranches()
+	if err != nil {
+		return nil, err
+	}
+
+	tips := make(map[string]map[plumbing.Hash]bool)
+	for _, b := range append(local, remote...) {
+		ref := refPrefix + b.Name
+		if b.Remote {
+			ref = remoteRefPrefix + b.Name
+		}
+		entries, err := readReflog(filepath.Join(gitDir, "logs", filepath.FromSlash(ref)))
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			if strings.HasPrefix(entry.message, createdFromPrefix) || !isHexHash(entry.newHash) {
+				continue
+			}
+			if tips[b.Name] == nil {
+				tips[b.Name] = make(map[plumbing.Hash]bool)
+			}
+			tips[b.Name][plumbing.NewHash(entry.newHash)] = true
+		}
+	}
+
+	return tips, nil
+}
+
+// finds the branch HEAD was on when it moved to the newly created branch
+func checkedOutFrom(headLog []reflogEntry, branch, hash string) string {
+	suffix := " to " + branch
+	for _, entry := range headLog {
+		if entry.newHash != hash || !strings.HasPrefix(entry.message, checkoutPrefix) {
+			continue
+		}
+		if from, ok := strings.CutSuffix(strings.TrimPrefix(entry.message, checkoutPrefix), suffix); ok {
+			return from
+		}
+	}
+	return ""
+}
+
+// turns a reflog source into a branch name, dropping anything that names a
+// commit rather than a branch
+func normalizeSourceName(source string) string {
+	switch {
+	case strings.HasPrefix(source, refPrefix):
+		return normalizeBranchName(source)
+	case strings.HasPrefix(source, remoteRefPrefix):
+		return normalizeRemoteBranchName(source)
+	case strings.HasPrefix(source, "refs/"), source == "HEAD", isHexHash(source):
+		return ""
+	case strings.ContainsAny(source, "~^@:"):
+		return ""
+	}
+	return source
+}
+
+func isHexHash(s string) bool {
+	if len(s) < 7 || len(s) > 40 {
+		return false
+	}
+	for _, c := range s {
+		if !strings.ContainsRune("0123456789abcdef", c) {
+			return false
+		}
+	}
+	return true
+}
+
+// reads a reflog file, oldest entry first; a missing file has no entries
+func readReflog(path string) ([]reflogEntry, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading reflog: %w", err)
+	}
+	defer f.Close()
+
+	var entries []reflogEntry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if entry, ok := parseReflogLine(scanner.Text()); ok {
+			entries = append(entries, entry)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading reflog: %w", err)
+	}
+
+	return entries, nil
+}
+
+// parses "<old> <new> <name> <<email>> <time> <tz>\t<message>"
+func parseReflogLine(line string) (reflogEntry, bool) {
+	header, message, ok := strings.Cut(line, "\t")
+	if !ok {
+		return reflogEntry{}, false
+	}
+
+	fields := strings.Fields(header)
+	if len(fields) < 2 {
+		return reflogEntry{}, false
+	}
+
+	return reflogEntry{
+		newHash: fields[1],
+		when:    parseReflogTime(fields),
+		message: message,
+	}, true
+}
+
+// reads the "<seconds> <zone>" ending a reflog header, or returns the zero
+// time when it is malformed
+func parseReflogTime(fields []string) time.Time {
+	if len(fields) < 4 {
+		return time.Time{}
+	}
+	seconds, err := strconv.ParseInt(fields[len(fields)-2], 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	zone, err := time.Parse("-0700", fields[len(fields)-1])
+	if err != nil {
+		return time.Time{}
+	}
+	return time.Unix(seconds, 0).In(zone.Location())
+}
@@ -0,0 +1,154 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetBranchSources(t *testing.T) {
+	path := createTestRepo(t)
+	gitRepo := openGitRepo(t, path)
+
+	head, err := gitRepo.Head()
+	require.NoError(t, err)
+	hash := head.Hash()
+
+	for _, name := range []string{"develop", "feat/a", "feat/b", "feat/c", "fix", "no-reflog", "expired"} {
+		createBranch(t, gitRepo, name)
+	}
+
+	writeReflog(t, path, "refs/heads/develop", hash, "branch: Created from master")
+	writeReflog(t, path, "refs/heads/feat/a", hash, "branch: Created from refs/heads/develop")
+	writeReflog(t, path, "refs/heads/feat/b", hash, "branch: Created from origin/main")
+	// git checkout -b feat/c while on develop
+	writeReflog(t, path, "refs/heads/feat/c", hash, "branch: Created from HEAD")
+	writeReflog(t, path, "HEAD", hash,
+		"commit (initial): Initial commit",
+		"checkout: moving from master to develop",
+		"checkout: moving from develop to feat/c")
+	writeReflog(t, path, "refs/heads/fix", hash, "branch: Created from "+hash.String()[:7])
+	writeReflog(t, path, "refs/heads/expired", hash, "commit: Fix typo")
+
+	repo := &Repository{repo: gitRepo}
+	sources, err := repo.GetBranchSources()
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]string{
+		"develop": "master",
+		"feat/a":  "develop",
+		"feat/b":  "origin/main",
+		"feat/c":  "develop",
+	}, sources)
+}
+
+func TestGetBranchCreationTimes(t *testing.T) {
+	path := createTestRepo(t)
+	gitRepo := openGitRepo(t, path)
+
+	head, err := gitRepo.Head()
+	require.NoError(t, err)
+	hash := head.Hash()
+
+	for _, name := range []string{"develop", "no-reflog", "expired"} {
+		createBranch(t, gitRepo, name)
+	}
+	writeReflog(t, path, "refs/heads/develop", hash, "branch: Created from master", "commit: Add feature")
+	writeReflog(t, path, "refs/heads/expired", hash, "commit: Fix typo")
+
+	repo := &Repository{repo: gitRepo}
+	created, err := repo.GetBranchCreationTimes()
+	require.NoError(t, err)
+
+	require.Len(t, created, 1)
+	assert.Equal(t, int64(1700000000), created["develop"].Unix())
+}
+
+func TestGetFormerTips(t *testing.T) {
+	path := createTestRepo(t)
+	gitRepo := openGitRepo(t, path)
+
+	head, err := gitRepo.Head()
+	require.NoError(t, err)
+	hash := head.Hash()
+
+	for _, name := range []string{"a", "b", "no-reflog"} {
+		createBranch(t, gitRepo, name)
+	}
+	writeReflog(t, path, "refs/heads/a", hash, "branch: Created from master", "commit: Add a1")
+	// created at the commit, but never moved there itself
+	writeReflog(t, path, "refs/heads/b", hash, "branch: Created from a")
+	require.NoError(t, gitRepo.Storer.SetReference(plumbing.NewHashReference(
+		plumbing.NewRemoteReferenceName("origin", "a"), hash)))
+	writeReflog(t, path, "refs/remotes/origin/a", hash, "update by push")
+
+	repo := &Repository{repo: gitRepo}
+	tips, err := repo.GetFormerTips()
+	require.NoError(t, err)
+
+	assert.Equal(t, map[plumbing.Hash]bool{hash: true}, tips["a"])
+	assert.Equal(t, map[plumbing.Hash]bool{hash: true}, tips["origin/a"])
+	assert.NotContains(t, tips, "b")
+	assert.NotContains(t, tips, "no-reflog")
+}
+
+func TestNormalizeSourceName(t *testing.T) {
+	tests := []struct {
+		source string
+		want   string
+	}{
+		{"main", "main"},
+		{"feat/x", "feat/x"},
+		{"refs/heads/main", "main"},
+		{"refs/remotes/origin/main", "origin/main"},
+		{"origin/main", "origin/main"},
+		{"refs/tags/v1.0", ""},
+		{"HEAD", ""},
+		{"HEAD~2", ""},
+		{"main@{1}", ""},
+		{"abc1234", ""},
+		{"cafe", "cafe"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			assert.Equal(t, tt.want, normalizeSourceName(tt.source))
+		})
+	}
+}
+
+func TestParseReflogLine(t *testing.T) {
+	line := "0000000000000000000000000000000000000000 1234567890abcdef1234567890abcdef12345678 Test User <test@example.com> 1700000000 +0100\tbranch: Created from main"
+
+	entry, ok := parseReflogLine(line)
+	require.True(t, ok)
+	assert.Equal(t, "1234567890abcdef1234567890abcdef12345678", entry.newHash)
+	assert.Equal(t, "branch: Created from main", entry.message)
+	assert.Equal(t, int64(1700000000), entry.when.Unix())
+	_, offset := entry.when.Zone()
+	assert.Equal(t, 3600, offset)
+
+	_, ok = parseReflogLine("garbage")
+	assert.False(t, ok)
+}
+
+// writes a reflog for ref with one entry per message, all at hash
+func writeReflog(t *testing.T, path, ref string, hash plumbing.Hash, messages ...string) {
+	t.Helper()
+
+	var lines []string
+	for _, message := range messages {
+		lines = append(lines, fmt.Sprintf("%s %s Test User <test@example.com> 1700000000 +0000\t%s",
+			plumbing.ZeroHash, hash, message))
+	}
+
+	file := filepath.Join(path, ".git", "logs", filepath.FromSlash(ref))
+	require.NoError(t, os.MkdirAll(filepath.Dir(file), 0755))
+	require.NoError(t, os.WriteFile(file, []byte(strings.Join(lines, "\n")+"\n"), 0644))
+}
@@ -3,15 +3,23 @@ package git
 import (
 	"errors"
 	"fmt"
+	"math"
 	"strings"
 
 	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
 	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/object/commitgraph"
 )
 
 const (
-	refPrefix = "refs/heads/"
+	refPrefix       = "refs/heads/"
+	remoteRefPrefix = "refs/remotes/"
+
+	// DetachedHeadName names the synthetic branch of a detached HEAD, which
+	// no real branch can be called
+	DetachedHeadName = "HEAD"
 )
 
 var (
@@ -20,13 +28,16 @@ var (
 )
 
 type Repository struct {
-	repo *git.Repository
+	repo  *git.Repository
+	nodes commitgraph.CommitNodeIndex
 }
 
 type Branch struct {
-	Name   string
-	Hash   plumbing.Hash
-	Commit *object.Commit
+	Name     string
+	Hash     plumbing.Hash
+	Commit   *object.Commit
+	Remote   bool
+	Detached bool
 }
 
 func Open(path string) (*Repository, error) {
@@ -38,19 +49,42 @@ func Open(path string) (*Repository, error) {
 		return nil, fmt.Errorf("opening repository: %w", err)
 	}
 
-	return &Repository{repo: repo}, nil
+	return &Repository{repo: repo, nodes: newCommitNodeIndex(repo)}, nil
 }
 
 func (r *Repository) GetCurrentBranch() (string, error) {
-	headRef, err := r.repo.Head()
+	headRef, err := r.repo.Storer.Reference(plumbing.HEAD)
 	if err != nil {
-		if err == plumbing.ErrReferenceNotFound {
-			return "", ErrDetachedHead
-		}
 		return "", fmt.Errorf("getting HEAD: %w", err)
 	}
 
-	return normalizeBranchName(headRef.Name().String()), nil
+	// an unborn branch has no commits yet but HEAD still names it
+	if headRef.Type() != plumbing.SymbolicReference {
+		return "", ErrDetachedHead
+	}
+
+	return normalizeBranchName(headRef.Target().String()), nil
+}
+
+// Returns a synthetic branch for a detached HEAD, named DetachedHeadName and
+// marked as Detached.
+func (r *Repository) GetDetachedHead() (Branch, error) {
+	headRef, err := r.repo.Head()
+	if err != nil {
+		return Branch{}, fmt.Errorf("getting HEAD: %w", err)
+	}
+
+	if headRef.Name() != plumbing.HEAD {
+		return Branch{}, fmt.Errorf("HEAD is not detached, it points at %s", headRef.Name().Short())
+	}
+
+	branch, err := r.loadBranch(headRef, DetachedHeadName)
+	if err != nil {
+		return Branch{}, err
+	}
+	branch.Detached = true
+
+	return branch, nil
 }
 
 func (r *Repository) GetBranches() ([]Branch, error) {
@@ -63,17 +97,12 @@ func (r *Repository) GetBranches() ([]Branch, error) {
 	var branches []Branch
 
 	err = branchIter.ForEach(func(ref *plumbing.Reference) error {
-		commit, err := r.repo.CommitObject(ref.Hash())
+		branch, err := r.loadBranch(ref, normalizeBranchName(ref.Name().String()))
 		if err != nil {
-			return fmt.Errorf("getting commit for %s: %w", ref.Name(), err)
+			return err
 		}
 
-		branches = append(branches, Branch{
-			Name:   normalizeBranchName(ref.Name().String()),
-			Hash:   ref.Hash(),
-			Commit: commit,
-		})
-
+		branches = append(branches, branch)
 		return nil
 	})
 
@@ -84,7 +113,73 @@ func (r *Repository) GetBranches() ([]Branch, error) {
 	return branches, nil
 }
 
-// Returns a map where each branch name maps to a set of its descendants
+// GetBranch returns the local branch with the given name.
+func (r *Repository) GetBranch(name string) (Branch, error) {
+	ref, err := r.repo.Reference(plumbing.NewBranchReferenceName(name), true)
+	if err != nil {
+		return Branch{}, fmt.Errorf("getting branch %s: %w", name, err)
+	}
+	return r.loadBranch(ref, name)
+}
+
+// Returns remote-tracking branches named like "origin/feat-x".
+// Symbolic refs such as origin/HEAD are skipped.
+func (r *Repository) GetRemoteBranches() ([]Branch, error) {
+	refIter, err := r.repo.References()
+	if err != nil {
+		return nil, fmt.Errorf("getting references: %w", err)
+	}
+	defer refIter.Close()
+
+	var branches []Branch
+
+	err = refIter.ForEach(func(ref *plumbing.Reference) error {
+		if !ref.Name().IsRemote() || ref.Type() != plumbing.HashReference {
+			return nil
+		}
+
+		name := normalizeRemoteBranchName(ref.Name().String())
+		if strings.HasSuffix(name, "/HEAD") {
+			return nil
+		}
+
+		branch, err := r.loadBranch(ref, name)
+		if err != nil {
+			return err
+		}
+		branch.Remote = true
+
+		branches = append(branches, branch)
+		return nil
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("iterating remote branches: %w", err)
+	}
+
+	return branches, nil
+}
+
+func (r *Repository) loadBranch(ref *plumbing.Reference, name string) (Branch, error) {
+	node, err := r.commitNodes().Get(ref.Hash())
+	if err != nil {
+		return Branch{}, fmt.Errorf("getting commit for %s: %w", ref.Name(), err)
+	}
+
+	commit, err := node.Commit()
+	if err != nil {
+		return Branch{}, fmt.Errorf("getting commit for %s: %w", ref.Name(), err)
+	}
+
+	return Branch{
+		Name:   name,
+		Hash:   ref.Hash(),
+		Commit: commit,
+	}, nil
+}
+
+// Returns a map where each branch name maps to a set of its descendants.
+// Branches pointing at the same commit are not related to each other.
 func (r *Repository) GetBranchRelationships(branches []Branch) (map[string]map[string]bool, error) {
 	relationships := make(map[string]map[string]bool)
 
@@ -92,46 +187,39 @@ func (r *Repository) GetBranchRelationships(branches []Branch) (map[string]map[s
 		relationships[branches[i].Name] = make(map[string]bool)
 	}
 
-	for i := 0; i < len(branches); i++ {
-		for j := i + 1; j < len(branches); j++ {
-			// Skip if same commit
-			if branches[i].Hash == branches[j].Hash {
-				continue
-			}
-
-			// Check if i is ancestor of j
-			isAncestor, err := branches[i].Commit.IsAncestor(branches[j].Commit)
-			if err != nil {
-				return nil, fmt.Errorf("checking ancestry %s->%s: %w",
-					branches[i].Name, branches[j].Name, err)
-			}
-			if isAncestor {
-				relationships[branches[i].Name][branches[j].Name] = true
-			}
+	reach, err := r.reachingTips(branches)
+	if err != nil {
+		return nil, fmt.Errorf("walking branch history: %w", err)
+	}
 
-			// Check if j is ancestor of i
-			isAncestor, err = branches[j].Commit.IsAncestor(branches[i].Commit)
-			if err != nil {
-				return nil, fmt.Errorf("checking ancestry %s->%s: %w",
-					branches[j].Name, branches[i].Name, err)
-			}
-			if isAncestor {
-				relationships[branches[j].Name][branches[i].Name] = true
+	for _, b := range branches {
+		reach[b.Hash].each(func(i int) {
+			if branches[i].Hash != b.Hash {
+				relationships[b.Name][branches[i].Name] = true
 			}
-		}
+		})
 	}
 
 	return relationships, nil
 }
 
+// Checks out a local branch. A remote-tracking branch such as "origin/feat-x"
+// is checked out by creating a local "feat-x" branch that tracks it.
 func (r *Repository) Checkout(branchName string) error {
 	w, err := r.repo.Worktree()
 	if err != nil {
 		return fmt.Errorf("getting worktree: %w", err)
 	}
 
+	refName := plumbing.ReferenceName(refPrefix + branchName)
+	if _, err := r.repo.Reference(refName, false); err == plumbing.ErrReferenceNotFound {
+		if refName, err = r.trackRemoteBranch(branchName); err != nil {
+			return fmt.Errorf("checkout %s: %w", branchName, err)
+		}
+	}
+
 	err = w.Checkout(&git.CheckoutOptions{
-		Branch: plumbing.ReferenceName(refPrefix + branchName),
+		Branch: refName,
 	})
 	if err != nil {
 		return fmt.Errorf("checkout %s: %w", branchName, err)
@@ -140,6 +228,120 @@ func (r *Repository) Checkout(branchName string) error {
 	return nil
 }
 
+// Deletes a local branch along with its configuration.
+func (r *Repository) DeleteBranch(branchName string) error {
+	refName := plumbing.NewBranchReferenceName(branchName)
+	if _, err := r.repo.Reference(refName, false); err != nil {
+		return fmt.Errorf("deleting %s: %w", branchName, err)
+	}
+
+	if err := r.repo.Storer.RemoveReference(refName); err != nil {
+		return fmt.Errorf("deleting %s: %w", branchName, err)
+	}
+
+	err := r.repo.DeleteBranch(branchName)
+	if err != nil && err != git.ErrBranchNotFound {
+		return fmt.Errorf("deleting config of %s: %w", branchName, err)
+	}
+
+	return nil
+}
+
+// creates a local branch tracking the given remote-tracking branch
+func (r *Repository) trackRemoteBranch(remoteBranch string) (plumbing.ReferenceName, error) {
+	remoteRef, err := r.repo.Reference(plumbing.ReferenceName(remoteRefPrefix+remoteBranch), true)
+	if err != nil {
+		return "", fmt.Errorf("resolving %s: %w", remoteBranch, err)
+	}
+
+	remote, name, ok := strings.Cut(remoteBranch, "/")
+	if !ok {
+		return "", fmt.Errorf("invalid remote branch name %q", remoteBranch)
+	}
+
+	localRef := plumbing.NewBranchReferenceName(name)
+	if _, err := r.repo.Reference(localRef, false); err == nil {
+		return "", fmt.Errorf("branch %s already exists", name)
+	}
+
+	if err := r.repo.Storer.SetReference(plumbing.NewHashReference(localRef, remoteRef.Hash())); err != nil {
+		return "", fmt.Errorf("creating branch %s: %w", name, err)
+	}
+
+	err = r.repo.CreateBranch(&config.Branch{
+		Name:   name,
+		Remote: remote,
+		Merge:  localRef,
+	})
+	if err != nil {
+		return "", fmt.Errorf("setting upstream for %s: %w", name, err)
+	}
+
+	return localRef, nil
+}
+
 func normalizeBranchName(refName string) string {
 	return strings.TrimPrefix(refName, refPrefix)
 }
+
+func normalizeRemoteBranchName(refName string) string {
+	return strings.TrimPrefix(refName, remoteRefPrefix)
+}
+
+// Returns how many commits tip has that base does not (ahead)
+// and how many commits base has that tip does not (behind).
+func (r *Repository) AheadBehind(base, tip Branch) (ahead, behind int, err error) {
+	if base.Hash == tip.Hash {
+		return 0, 0, nil
+	}
+
+	sides, err := r.divergence(base, tip)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	for _, reach := range sides {
+		switch {
+		case reach.has(0) && reach.has(1):
+		case reach.has(1):
+			ahead++
+		case reach.has(0):
+			behind++
+		}
+	}
+
+	return ahead, behind, nil
+}
+
+// Returns for every commit walked which of base (0) and tip (1) reach it.
+// Only the commits above the merge-base are walked: the walk ends once
+// every queued commit can be reached from both branches, so every commit
+// only one of them has is in the result.
+func (r *Repository) divergence(base, tip Branch) (map[plumbing.Hash]bitset, error) {
+	w, _, err := r.newHistoryWalk([]Branch{base, tip})
+	if err != nil {
+		return nil, err
+	}
+
+	slop := walkSlop
+	for w.queue.Len() > 0 {
+		if w.queueShared() {
+			// with generation numbers nothing below can be one-sided
+			if w.queue[0].generation != math.MaxUint64 {
+				break
+			}
+			slop--
+			if slop == 0 {
+				break
+			}
+		} else {
+			slop = walkSlop
+		}
+
+		if err := w.expand(w.pop()); err != nil {
+			return nil, err
+		}
+	}
+
+	return w.reach, nil
+}
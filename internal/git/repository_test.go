@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
 	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/object"
 	"github.com/stretchr/testify/assert"
@@ -84,6 +85,22 @@ func TestGetCurrentBranch(t *testing.T) {
 			want:    "feature",
 			wantErr: nil,
 		},
+		{
+			name: "detached HEAD",
+			setup: func(t *testing.T, repo *git.Repository) {
+				detachHead(t, repo)
+			},
+			wantErr: ErrDetachedHead,
+		},
+		{
+			name: "unborn branch",
+			setup: func(t *testing.T, repo *git.Repository) {
+				head := plumbing.NewSymbolicReference(plumbing.HEAD, plumbing.NewBranchReferenceName("orphan"))
+				require.NoError(t, repo.Storer.SetReference(head))
+			},
+			want:    "orphan",
+			wantErr: nil,
+		},
 	}
 
 	for _, tt := range tests {
@@ -108,6 +125,31 @@ func TestGetCurrentBranch(t *testing.T) {
 	}
 }
 
+func TestGetDetachedHead(t *testing.T) {
+	path := createTestRepo(t)
+	gitRepo := openGitRepo(t, path)
+	repo := &Repository{repo: gitRepo}
+
+	_, err := repo.GetDetachedHead()
+	assert.Error(t, err)
+
+	commitFile(t, gitRepo, "file1.txt", "content1")
+	hash := detachHead(t, gitRepo)
+
+	head, err := repo.GetDetachedHead()
+	require.NoError(t, err)
+	assert.Equal(t, DetachedHeadName, head.Name)
+	assert.True(t, head.Detached)
+	assert.Equal(t, hash, head.Hash)
+	assert.NotNil(t, head.Commit)
+
+	// checking out a branch leaves the detached state
+	require.NoError(t, repo.Checkout("master"))
+	current, err := repo.GetCurrentBranch()
+	require.NoError(t, err)
+	assert.Equal(t, "master", current)
+}
+
 func TestGetBranches(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -165,6 +207,60 @@ func TestGetBranches(t *testing.T) {
 	}
 }
 
+func TestGetRemoteBranches(t *testing.T) {
+	path := createTestRepo(t)
+	gitRepo := openGitRepo(t, path)
+
+	createBranch(t, gitRepo, "feature")
+	addRemote(t, gitRepo, "origin")
+
+	// local-only branch must not show up as remote
+	createBranch(t, gitRepo, "local-only")
+
+	repo := &Repository{repo: gitRepo}
+	branches, err := repo.GetRemoteBranches()
+	require.NoError(t, err)
+
+	names := make([]string, len(branches))
+	for i, b := range branches {
+		names[i] = b.Name
+		assert.True(t, b.Remote)
+		assert.NotNil(t, b.Commit)
+	}
+	assert.ElementsMatch(t, []string{"origin/master", "origin/feature"}, names)
+
+	local, err := repo.GetBranches()
+	require.NoError(t, err)
+	for _, b := range local {
+		assert.False(t, b.Remote)
+	}
+}
+
+func TestCheckout_RemoteBranch(t *testing.T) {
+	path := createTestRepo(t)
+	gitRepo := openGitRepo(t, path)
+
+	createBranch(t, gitRepo, "feature")
+	addRemote(t, gitRepo, "origin")
+	deleteBranch(t, gitRepo, "feature")
+
+	repo := &Repository{repo: gitRepo}
+	require.NoError(t, repo.Checkout("origin/feature"))
+
+	current, err := repo.GetCurrentBranch()
+	require.NoError(t, err)
+	assert.Equal(t, "feature", current)
+
+	cfg, err := gitRepo.Config()
+	require.NoError(t, err)
+	require.Contains(t, cfg.Branches, "feature")
+	assert.Equal(t, "origin", cfg.Branches["feature"].Remote)
+
+	// a local branch with the same name already exists now
+	require.NoError(t, repo.Checkout("master"))
+	assert.Error(t, repo.Checkout("origin/feature"))
+}
+
 func TestGetBranchRelationships(t *testing.T) {
 	tests := []struct {
 		name  string
@@ -259,6 +355,68 @@ func TestGetBranchRelationships(t *testing.T) {
 	}
 }
 
+func TestAheadBehind(t *testing.T) {
+	tests := []struct {
+		name       string
+		setup      func(t *testing.T, repo *git.Repository)
+		wantAhead  int
+		wantBehind int
+	}{
+		{
+			name: "same commit",
+			setup: func(t *testing.T, repo *git.Repository) {
+				createBranch(t, repo, "feature")
+			},
+		},
+		{
+			name: "feature ahead",
+			setup: func(t *testing.T, repo *git.Repository) {
+				createBranch(t, repo, "feature")
+				checkoutBranch(t, repo, "feature")
+				commitFile(t, repo, "file1.txt", "content1")
+				commitFile(t, repo, "file2.txt", "content2")
+			},
+			wantAhead: 2,
+		},
+		{
+			name: "diverged",
+			setup: func(t *testing.T, repo *git.Repository) {
+				createBranch(t, repo, "feature")
+				checkoutBranch(t, repo, "feature")
+				commitFile(t, repo, "file1.txt", "content1")
+				checkoutBranch(t, repo, "master")
+				commitFile(t, repo, "file2.txt", "content2")
+				commitFile(t, repo, "file3.txt", "content3")
+				commitFile(t, repo, "file4.txt", "content4")
+			},
+			wantAhead:  1,
+			wantBehind: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := createTestRepo(t)
+			gitRepo := openGitRepo(t, path)
+			tt.setup(t, gitRepo)
+
+			repo := &Repository{repo: gitRepo}
+			branches, err := repo.GetBranches()
+			require.NoError(t, err)
+
+			byName := make(map[string]Branch)
+			for _, b := range branches {
+				byName[b.Name] = b
+			}
+
+			ahead, behind, err := repo.AheadBehind(byName["master"], byName["feature"])
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantAhead, ahead)
+			assert.Equal(t, tt.wantBehind, behind)
+		})
+	}
+}
+
 func TestNormalizeBranchName(t *testing.T) {
 	tests := []struct {
 		input string
@@ -334,6 +492,56 @@ func createBranch(t *testing.T, repo *git.Repository, name string) {
 	require.NoError(t, err)
 }
 
+// checks out the current commit directly and returns its hash
+func detachHead(t *testing.T, repo *git.Repository) plumbing.Hash {
+	t.Helper()
+
+	head, err := repo.Head()
+	require.NoError(t, err)
+
+	w, err := repo.Worktree()
+	require.NoError(t, err)
+
+	err = w.Checkout(&git.CheckoutOptions{Hash: head.Hash()})
+	require.NoError(t, err)
+
+	return head.Hash()
+}
+
+func deleteBranch(t *testing.T, repo *git.Repository, name string) {
+	t.Helper()
+
+	err := repo.Storer.RemoveReference(plumbing.NewBranchReferenceName(name))
+	require.NoError(t, err)
+}
+
+// pushes all local branches to a new bare repository and fetches them back
+// as remote-tracking branches
+func addRemote(t *testing.T, repo *git.Repository, name string) {
+	t.Helper()
+
+	remoteDir := t.TempDir()
+	_, err := git.PlainInit(remoteDir, true)
+	require.NoError(t, err)
+
+	_, err = repo.CreateRemote(&config.RemoteConfig{
+		Name: name,
+		URLs: []string{remoteDir},
+	})
+	require.NoError(t, err)
+
+	err = repo.Push(&git.PushOptions{
+		RemoteName: name,
+		RefSpecs:   []config.RefSpec{"refs/heads/*:refs/heads/*"},
+	})
+	require.NoError(t, err)
+
+	err = repo.Fetch(&git.FetchOptions{RemoteName: name})
+	if err != git.NoErrAlreadyUpToDate {
+		require.NoError(t, err)
+	}
+}
+
 func checkoutBranch(t *testing.T, repo *git.Repository, name string) {
 	t.Helper()
 
@@ -0,0 +1,251 @@
+package git
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"fmt"
+	"hash"
+	"sort"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	fdiff "github.com/go-git/go-git/v5/plumbing/format/diff"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// Returns the branches whose changes landed in trunk as different commits,
+// as happens with squash and rebase merges. A branch counts as squash-merged
+// when the combined diff of its unique commits matches a single trunk commit
+// since the fork point, and as rebase-merged when each of its unique commits
+// has an equivalent trunk commit. Diffs are compared by patch-id, which
+// ignores whitespace, line numbers and context. Branches whose tips are
+// contained in trunk are left to GetMergedBranches.
+func (r *Repository) GetSquashMergedBranches(branches []Branch, trunk Branch) (map[string]bool, error) {
+	merged := make(map[string]bool)
+	patchIDs := make(map[plumbing.Hash]plumbing.Hash)
+
+	commitPatchID := func(c *object.Commit) (plumbing.Hash, error) {
+		if id, ok := patchIDs[c.Hash]; ok {
+			return id, nil
+		}
+		id, err := r.commitPatchID(c)
+		if err != nil {
+			return plumbing.ZeroHash, fmt.Errorf("computing patch-id of %s: %w", c.Hash, err)
+		}
+		patchIDs[c.Hash] = id
+		return id, nil
+	}
+
+	for _, b := range branches {
+		if b.Hash == trunk.Hash {
+			continue
+		}
+
+		base, trunkCommits, branchCommits, err := r.commitsApart(trunk, b)
+		if err != nil {
+			return nil, err
+		}
+		// unrelated histories, the tip is already contained in trunk, or
+		// trunk has nothing since
+		if base == nil || len(trunkCommits) == 0 {
+			continue
+		}
+
+		trunkIDs := make(map[plumbing.Hash]bool, len(trunkCommits))
+		for _, c := range trunkCommits {
+			id, err := commitPatchID(c)
+			if err != nil {
+				return nil, err
+			}
+			trunkIDs[id] = true
+		}
+
+		combined, err := r.treePatchID(base, b.Commit)
+		if err != nil {
+			return nil, fmt.Errorf("computing patch-id of %s: %w", b.Name, err)
+		}
+		if combined == plumbing.ZeroHash {
+			continue
+		}
+		if trunkIDs[combined] {
+			merged[b.Name] = true
+			continue
+		}
+
+		rebased := true
+		for _, c := range branchCommits {
+			id, err := commitPatchID(c)
+			if err != nil {
+				return nil, err
+			}
+			if id != plumbing.ZeroHash && !trunkIDs[id] {
+				rebased = false
+				break
+			}
+		}
+		if rebased {
+			merged[b.Name] = true
+		}
+	}
+
+	return merged, nil
+}
+
+// Returns the merge-base of trunk and branch with the non-merge commits
+// only trunk has and those only branch has, walking no further down than
+// where the two meet. The merge-base is the newest commit both have that a
+// commit of branch's own builds on, or nil when there is none.
+func (r *Repository) commitsApart(trunk, branch Branch) (base *object.Commit, onlyTrunk, onlyBranch []*object.Commit, err error) {
+	sides, err := r.divergence(trunk, branch)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	for hash, reach := range sides {
+		if reach.has(0) == reach.has(1) {
+			continue
+		}
+		c, err := r.repo.CommitObject(hash)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("getting commit %s: %w", hash, err)
+		}
+
+		if reach.has(1) {
+			for _, parent := range c.ParentHashes {
+				if reach := sides[parent]; reach == nil || !reach.has(0) {
+					continue
+				}
+				p, err := r.repo.CommitObject(parent)
+				if err != nil {
+					return nil, nil, nil, fmt.Errorf("getting commit %s: %w", parent, err)
+				}
+				if base == nil || newerCommit(p, base) {
+					base = p
+				}
+			}
+		}
+
+		if c.NumParents() > 1 {
+			continue
+		}
+		if reach.has(0) {
+			onlyTrunk = append(onlyTrunk, c)
+		} else {
+			onlyBranch = append(onlyBranch, c)
+		}
+	}
+
+	return base, onlyTrunk, onlyBranch, nil
+}
+
+// orders commits by committer date, then hash, so the choice is stable
+func newerCommit(x, y *object.Commit) bool {
+	if !x.Committer.When.Equal(y.Committer.When) {
+		return x.Committer.When.After(y.Committer.When)
+	}
+	return bytes.Compare(x.Hash[:], y.Hash[:]) < 0
+}
+
+// patch-id of the changes a commit introduces on top of its first parent
+func (r *Repository) commitPatchID(c *object.Commit) (plumbing.Hash, error) {
+	var parent *object.Commit
+	if c.NumParents() > 0 {
+		var err error
+		if parent, err = c.Parent(0); err != nil {
+			return plumbing.ZeroHash, err
+		}
+	}
+	return r.treePatchID(parent, c)
+}
+
+// Computes a patch-id for the diff between two commits, in the spirit of
+// git patch-id: only added and removed lines are hashed, with whitespace
+// stripped, so the same change applied on a different base gets the same id.
+// Returns the zero hash when there is no difference.
+func (r *Repository) treePatchID(from, to *object.Commit) (plumbing.Hash, error) {
+	var fromTree *object.Tree
+	if from != nil {
+		var err error
+		if fromTree, err = from.Tree(); err != nil {
+			return plumbing.ZeroHash, err
+		}
+	}
+
+	toTree, err := to.Tree()
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+
+	changes, err := object.DiffTree(fromTree, toTree)
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+	if len(changes) == 0 {
+		return plumbing.ZeroHash, nil
+	}
+
+	patch, err := changes.Patch()
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+
+	filePatches := patch.FilePatches()
+	sort.Slice(filePatches, func(i, j int) bool {
+		return filePatchPath(filePatches[i]) < filePatchPath(filePatches[j])
+	})
+
+	h := sha1.New()
+	for _, fp := range filePatches {
+		writePatchID(h, fp)
+	}
+
+	var id plumbing.Hash
+	copy(id[:], h.Sum(nil))
+	return id, nil
+}
+
+func writePatchID(h hash.Hash, fp fdiff.FilePatch) {
+	from, to := fp.Files()
+	fromPath, toPath := "/dev/null", "/dev/null"
+	if from != nil {
+		fromPath = from.Path()
+	}
+	if to != nil {
+		toPath = to.Path()
+	}
+	fmt.Fprintf(h, "diff %s %s\n", fromPath, toPath)
+
+	if fp.IsBinary() {
+		if to != nil {
+			fmt.Fprintf(h, "binary %s\n", to.Hash())
+		}
+		return
+	}
+
+	for _, chunk := range fp.Chunks() {
+		var op string
+		switch chunk.Type() {
+		case fdiff.Add:
+			op = "+"
+		case fdiff.Delete:
+			op = "-"
+		default:
+			continue
+		}
+
+		for _, line := range strings.SplitAfter(chunk.Content(), "\n") {
+			if line == "" {
+				continue
+			}
+			fmt.Fprintf(h, "%s%s\n", op, strings.Join(strings.Fields(line), ""))
+		}
+	}
+}
+
+func filePatchPath(fp fdiff.FilePatch) string {
+	from, to := fp.Files()
+	if to != nil {
+		return to.Path()
+	}
+	return from.Path()
+}
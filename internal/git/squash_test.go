@@ -0,0 +1,183 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetSquashMergedBranches(t *testing.T) {
+	path := createTestRepo(t)
+	gitRepo := openGitRepo(t, path)
+
+	// squashed: two commits that land on master as one
+	createBranch(t, gitRepo, "squashed")
+	checkoutBranch(t, gitRepo, "squashed")
+	commitFile(t, gitRepo, "a.txt", "a\n")
+	commitFile(t, gitRepo, "b.txt", "b\n")
+
+	// rebased: each commit is replayed on master
+	checkoutBranch(t, gitRepo, "master")
+	createBranch(t, gitRepo, "rebased")
+	checkoutBranch(t, gitRepo, "rebased")
+	commitFile(t, gitRepo, "c.txt", "c\n")
+
+	// open: its change never lands
+	checkoutBranch(t, gitRepo, "master")
+	createBranch(t, gitRepo, "open")
+	checkoutBranch(t, gitRepo, "open")
+	commitFile(t, gitRepo, "d.txt", "d\n")
+
+	// merged: contained in master, reported by GetMergedBranches instead
+	checkoutBranch(t, gitRepo, "master")
+	createBranch(t, gitRepo, "merged")
+
+	commitFiles(t, gitRepo, "Squash squashed", map[string]string{"a.txt": "a\n", "b.txt": "b\n"})
+	commitFile(t, gitRepo, "unrelated.txt", "x\n")
+	commitFiles(t, gitRepo, "Replay c", map[string]string{"c.txt": "c  \n"})
+
+	repo := &Repository{repo: gitRepo}
+	branches, err := repo.GetBranches()
+	require.NoError(t, err)
+
+	var trunk Branch
+	for _, b := range branches {
+		if b.Name == "master" {
+			trunk = b
+		}
+	}
+
+	merged, err := repo.GetSquashMergedBranches(branches, trunk)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]bool{"squashed": true, "rebased": true}, merged)
+}
+
+func TestCommitsApart(t *testing.T) {
+	gitRepo, err := git.Init(memory.NewStorage(), nil)
+	require.NoError(t, err)
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(minutes int) time.Time { return now.Add(time.Duration(minutes) * time.Minute) }
+
+	// the history below leads to a missing commit, so walking down to it
+	// fails
+	below := plumbing.NewHash("0123456789abcdef0123456789abcdef01234567")
+	for i := 0; i < 20; i++ {
+		below = storeCommit(t, gitRepo, "old", at(i), below)
+	}
+	m1 := storeCommit(t, gitRepo, "m1", at(30), below)
+	m2 := storeCommit(t, gitRepo, "m2", at(31), m1)
+	m3 := storeCommit(t, gitRepo, "m3", at(32), m2)
+	// the branch merged the trunk once, so it builds on m2
+	b1 := storeCommit(t, gitRepo, "b1", at(33), m1)
+	merge := storeCommit(t, gitRepo, "merge", at(34), b1, m2)
+	b2 := storeCommit(t, gitRepo, "b2", at(35), merge)
+
+	setBranchRef(t, gitRepo, "main", m3)
+	setBranchRef(t, gitRepo, "feature", b2)
+
+	repo := &Repository{repo: gitRepo}
+	trunk, err := repo.GetBranch("main")
+	require.NoError(t, err)
+	branch, err := repo.GetBranch("feature")
+	require.NoError(t, err)
+
+	base, onlyTrunk, onlyBranch, err := repo.commitsApart(trunk, branch)
+	require.NoError(t, err)
+	require.NotNil(t, base)
+	assert.Equal(t, m2, base.Hash)
+
+	hashes := func(commits []*object.Commit) []plumbing.Hash {
+		var got []plumbing.Hash
+		for _, c := range commits {
+			got = append(got, c.Hash)
+		}
+		return got
+	}
+	assert.ElementsMatch(t, []plumbing.Hash{m3}, hashes(onlyTrunk))
+	// merges are left out
+	assert.ElementsMatch(t, []plumbing.Hash{b1, b2}, hashes(onlyBranch))
+
+	// a tip contained in trunk has no commits of its own
+	setBranchRef(t, gitRepo, "old", m1)
+	old, err := repo.GetBranch("old")
+	require.NoError(t, err)
+	base, _, onlyBranch, err = repo.commitsApart(trunk, old)
+	require.NoError(t, err)
+	assert.Nil(t, base)
+	assert.Empty(t, onlyBranch)
+}
+
+func TestTreePatchID(t *testing.T) {
+	path := createTestRepo(t)
+	gitRepo := openGitRepo(t, path)
+	repo := &Repository{repo: gitRepo}
+
+	createBranch(t, gitRepo, "other")
+	commitFile(t, gitRepo, "file.txt", "one\ntwo\n")
+	first := headCommit(t, gitRepo)
+
+	// the same change on a different base has the same patch-id
+	checkoutBranch(t, gitRepo, "other")
+	commitFile(t, gitRepo, "unrelated.txt", "x\n")
+	commitFile(t, gitRepo, "file.txt", "one\n two\n")
+	second := headCommit(t, gitRepo)
+
+	firstID, err := repo.commitPatchID(first)
+	require.NoError(t, err)
+	secondID, err := repo.commitPatchID(second)
+	require.NoError(t, err)
+	assert.Equal(t, firstID, secondID)
+
+	commitFile(t, gitRepo, "file.txt", "one\nthree\n")
+	third := headCommit(t, gitRepo)
+	thirdID, err := repo.commitPatchID(third)
+	require.NoError(t, err)
+	assert.NotEqual(t, secondID, thirdID)
+
+	// no changes between a commit and itself
+	emptyID, err := repo.treePatchID(third, third)
+	require.NoError(t, err)
+	assert.True(t, emptyID.IsZero())
+}
+
+func commitFiles(t *testing.T, repo *git.Repository, message string, files map[string]string) {
+	t.Helper()
+
+	w, err := repo.Worktree()
+	require.NoError(t, err)
+
+	for filename, content := range files {
+		path := filepath.Join(w.Filesystem.Root(), filename)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+		_, err = w.Add(filename)
+		require.NoError(t, err)
+	}
+
+	_, err = w.Commit(message, &git.CommitOptions{
+		Author: &object.Signature{
+			Name:  "Test User",
+			Email: "test@example.com",
+			When:  time.Now(),
+		},
+	})
+	require.NoError(t, err)
+}
+
+func headCommit(t *testing.T, repo *git.Repository) *object.Commit {
+	t.Helper()
+
+	head, err := repo.Head()
+	require.NoError(t, err)
+	commit, err := repo.CommitObject(head.Hash())
+	require.NoError(t, err)
+	return commit
+}
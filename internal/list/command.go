@@ -1,24 +1,45 @@
 package list
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"os"
-	"time"
 
 	"github.com/spf13/cobra"
 
+	"github.com/mucansever/gittree/internal/branchtree"
 	"github.com/mucansever/gittree/internal/git"
 	"github.com/mucansever/gittree/internal/tree"
 )
 
 const (
 	defaultPath = "."
+
+	outputText    = "text"
+	outputJSON    = "json"
+	outputDOT     = "dot"
+	outputMermaid = "mermaid"
+)
+
+var (
+	ErrUnknownOutputFormat = errors.New("unknown output format")
 )
 
 type Options struct {
-	Path   string
-	Output io.Writer
+	Path         string
+	Remotes      bool
+	All          bool
+	Infer        string
+	Trunk        string
+	DAG          bool
+	SquashMerged bool
+	Sort         string
+	Format       string
+	Verbose      bool
+	Color        string
+	OutputFormat string
+	Output       io.Writer
 }
 
 func NewListCommand() *cobra.Command {
@@ -31,7 +52,51 @@ func NewListCommand() *cobra.Command {
 		Short: "List branches in a tree structure",
 		Long: `List all branches of a git repository in a hierarchical tree structure.
 The tree shows ancestor-descendant relationships between branches.
-The current HEAD branch is marked with an asterisk (*).`,
+The current HEAD branch is marked with an asterisk (*). A detached HEAD
+is placed in the tree as "HEAD (detached at abc1234)*".
+Remote-tracking branches are shown with their remote prefix (origin/main).
+
+With --infer=fork-point, a branch whose parent has moved on is still placed
+under it, at the branch it shares the most recent merge-base with, and is
+marked as diverged. With --infer=reflog, a branch is placed under the branch
+its reflog says it was created from, falling back to ancestry when that is
+unknown. Branches already contained in the trunk are tagged [merged].
+With --squash-merged, branches whose changes landed through a squash or rebase
+merge are tagged [squash-merged]; finding them compares the patch of every
+trunk commit, which takes a while on long histories.
+
+A branch that descends from several unrelated branches is listed under each
+of them. With --dag it is listed once, under the parent with the most recent
+commit, followed by "(also from ...)" naming the others.
+
+Sibling branches are listed by name. --sort=date lists the most recently
+committed first, --sort=creation the oldest branch first according to the
+reflog, and --sort=ahead the ones with the most commits of their own first.
+
+--output=json writes the tree as a JSON document instead; its schema is
+described in the README. --output=dot and --output=mermaid draw it as a
+Graphviz digraph or a Mermaid flowchart, with the current branch highlighted.
+
+--format replaces the label of each branch with a Go text/template, such as
+'{{.Name}} {{.ShortHash}} {{.Subject}}'. Templates can use .Name, .Aliases,
+.Current, .Hash, .ShortHash, .Subject, .Author, .AuthorEmail, .Relative,
+.LastCommit, .Ahead, .Behind, .Merged, .SquashMerged, .Recorded and .AlsoFrom.
+The presets short, oneline and full cover common cases.
+
+--verbose adds the short hash, commit date, author with email and subject of
+every branch in aligned columns after the tree, like git branch -vv.
+
+--color=auto highlights the current branch, dims merged branches and ones
+without commits for three months, and colors the age of recent commits when
+writing to a terminal, unless NO_COLOR is set. --color=always and
+--color=never force it on or off.`,
+		// bad flag values are reported before the repository is opened
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if err := tree.SortOrder(opts.Sort).Validate(); err != nil {
+				return err
+			}
+			return branchtree.Inference(opts.Infer).Validate()
+		},
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return runList(opts)
 		},
@@ -39,48 +104,88 @@ The current HEAD branch is marked with an asterisk (*).`,
 
 	cmd.Flags().StringVarP(&opts.Path, "path", "p", defaultPath,
 		"Path to the git repository")
+	cmd.Flags().BoolVarP(&opts.Remotes, "remotes", "r", false,
+		"List remote-tracking branches only")
+	cmd.Flags().BoolVarP(&opts.All, "all", "a", false,
+		"List both local and remote-tracking branches")
+	cmd.Flags().StringVar(&opts.Infer, "infer", string(branchtree.InferAncestry),
+		"How branch parents are inferred: ancestry, fork-point or reflog")
+	cmd.Flags().StringVar(&opts.Trunk, "trunk", "",
+		"Branch that merged branches are detected against (default main or master)")
+	cmd.Flags().BoolVar(&opts.DAG, "dag", false,
+		"Show every branch once, listing further parents as \"also from\"")
+	cmd.Flags().BoolVar(&opts.SquashMerged, "squash-merged", false,
+		"Tag branches that were squash- or rebase-merged into the trunk")
+	cmd.Flags().StringVar(&opts.Sort, "sort", string(tree.SortName),
+		"Order of sibling branches: name, date, creation or ahead")
+	cmd.Flags().StringVar(&opts.Format, "format", "",
+		"Template for branch labels, or one of the presets short, oneline and full")
+	cmd.Flags().BoolVarP(&opts.Verbose, "verbose", "v", false,
+		"Show the hash, date, author and subject of every branch")
+	cmd.Flags().StringVar(&opts.Color, "color", string(tree.ColorAuto),
+		"When to color the tree: auto, always or never")
+	cmd.Flags().StringVarP(&opts.OutputFormat, "output", "o", outputText,
+		"Output format: text, json, dot or mermaid")
 
 	return cmd
 }
 
 func runList(opts *Options) error {
-	repo, err := git.Open(opts.Path)
-	if err != nil {
-		return fmt.Errorf("failed to open repository: %w", err)
+	switch opts.OutputFormat {
+	case "", outputText, outputJSON, outputDOT, outputMermaid:
+	default:
+		return fmt.Errorf("%w: %q", ErrUnknownOutputFormat, opts.OutputFormat)
 	}
 
-	branches, err := repo.GetBranches()
+	format, err := tree.ParseFormat(opts.Format)
 	if err != nil {
-		return fmt.Errorf("failed to get branches: %w", err)
+		return err
 	}
-
-	if len(branches) == 0 {
-		fmt.Fprintln(opts.Output, "No branches found")
-		return nil
+	printer := tree.NewPrinter(opts.Output)
+	printer.SetFormat(format)
+	printer.SetVerbose(opts.Verbose)
+	if err := printer.SetColor(tree.ColorMode(opts.Color)); err != nil {
+		return err
 	}
 
-	currentBranch, err := repo.GetCurrentBranch()
+	repo, err := git.Open(opts.Path)
 	if err != nil {
-		return fmt.Errorf("failed to get current branch: %w", err)
+		return fmt.Errorf("failed to open repository: %w", err)
 	}
 
-	relationships, err := repo.GetBranchRelationships(branches)
+	t, err := branchtree.Load(repo, branchtree.Options{
+		Remotes:      opts.Remotes,
+		All:          opts.All,
+		Inference:    branchtree.Inference(opts.Infer),
+		Trunk:        opts.Trunk,
+		DAG:          opts.DAG,
+		Sort:         tree.SortOrder(opts.Sort),
+		SquashMerged: opts.SquashMerged,
+	})
+	if errors.Is(err, branchtree.ErrNoBranches) {
+		if opts.OutputFormat != "" && opts.OutputFormat != outputText {
+			return writeTree(opts, nil, nil)
+		}
+		fmt.Fprintln(opts.Output, "No branches found")
+		return nil
+	}
 	if err != nil {
-		return fmt.Errorf("failed to analyze branch relationships: %w", err)
+		return err
 	}
 
-	meta := make(map[string]time.Time)
-	for _, b := range branches {
-		meta[b.Name] = b.Commit.Committer.When
-	}
+	return writeTree(opts, t, printer)
+}
 
-	builder := tree.NewBuilder(relationships, meta)
-	t, err := builder.Build(currentBranch)
-	if err != nil {
-		return fmt.Errorf("failed to build tree: %w", err)
+func writeTree(opts *Options, t *tree.Tree, printer *tree.Printer) error {
+	switch opts.OutputFormat {
+	case outputJSON:
+		return tree.WriteJSON(opts.Output, t)
+	case outputDOT:
+		return tree.WriteDOT(opts.Output, t)
+	case outputMermaid:
+		return tree.WriteMermaid(opts.Output, t)
 	}
 
-	printer := tree.NewPrinter(opts.Output)
 	printer.Print(t)
 
 	return nil
@@ -2,16 +2,21 @@ package list
 
 import (
 	"bytes"
+	"encoding/json"
 	"os"
 	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
 	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/object"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/mucansever/gittree/internal/branchtree"
+	"github.com/mucansever/gittree/internal/tree"
 )
 
 func TestRunList(t *testing.T) {
@@ -79,6 +84,182 @@ func TestRunList(t *testing.T) {
 	}
 }
 
+func TestRunList_Remotes(t *testing.T) {
+	path := createTestRepo(t)
+	repo := openRepo(t, path)
+	createBranch(t, repo, "feature")
+	addRemote(t, repo, "origin")
+	createBranch(t, repo, "local-only")
+
+	tests := []struct {
+		name    string
+		remotes bool
+		all     bool
+		want    []string
+		notWant []string
+	}{
+		{
+			name:    "local only by default",
+			want:    []string{"master*", "feature", "local-only"},
+			notWant: []string{"origin/"},
+		},
+		{
+			name:    "remotes only",
+			remotes: true,
+			want:    []string{"origin/master", "origin/feature"},
+			notWant: []string{"local-only"},
+		},
+		{
+			name: "all branches",
+			all:  true,
+			want: []string{"master*", "local-only", "origin/master", "origin/feature"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+
+			err := runList(&Options{
+				Path:    path,
+				Remotes: tt.remotes,
+				All:     tt.all,
+				Output:  &buf,
+			})
+			require.NoError(t, err)
+
+			output := buf.String()
+			for _, want := range tt.want {
+				assert.Contains(t, output, want)
+			}
+			for _, notWant := range tt.notWant {
+				assert.NotContains(t, output, notWant)
+			}
+		})
+	}
+}
+
+func TestRunList_JSON(t *testing.T) {
+	path := createTestRepo(t)
+	repo := openRepo(t, path)
+	createBranch(t, repo, "feature")
+
+	var buf bytes.Buffer
+	err := runList(&Options{Path: path, OutputFormat: "json", Output: &buf})
+	require.NoError(t, err)
+
+	var doc tree.JSONTree
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &doc))
+	assert.Equal(t, tree.JSONSchemaVersion, doc.SchemaVersion)
+	assert.Equal(t, "master", doc.Current)
+
+	require.Len(t, doc.Roots, 1)
+	master := doc.Roots[0]
+	assert.Equal(t, "master", master.Name)
+	assert.True(t, master.Current)
+	assert.Len(t, master.Hash, 40)
+	assert.Equal(t, []string{"feature"}, master.Aliases)
+
+	err = runList(&Options{Path: path, OutputFormat: "yaml", Output: &buf})
+	assert.ErrorIs(t, err, ErrUnknownOutputFormat)
+}
+
+func TestRunList_Graph(t *testing.T) {
+	path := createTestRepo(t)
+	repo := openRepo(t, path)
+	createBranch(t, repo, "feature")
+
+	tests := []struct {
+		format string
+		want   []string
+	}{
+		{"dot", []string{"digraph gittree {", `"master" [label="master, feature`, "fillcolor"}},
+		{"mermaid", []string{"flowchart TD", `n0["master, feature`, "class n0 current"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := runList(&Options{Path: path, OutputFormat: tt.format, Output: &buf})
+			require.NoError(t, err)
+			for _, want := range tt.want {
+				assert.Contains(t, buf.String(), want)
+			}
+		})
+	}
+}
+
+func TestRunList_Format(t *testing.T) {
+	path := createTestRepo(t)
+
+	var buf bytes.Buffer
+	err := runList(&Options{Path: path, Format: "oneline", Output: &buf})
+	require.NoError(t, err)
+	assert.Regexp(t, `^\.\n└── master\* [0-9a-f]{7} Initial commit\n$`, buf.String())
+
+	err = runList(&Options{Path: path, Format: "{{.Missing}}", Output: &buf})
+	assert.ErrorIs(t, err, tree.ErrInvalidFormat)
+}
+
+func TestRunList_Verbose(t *testing.T) {
+	path := createTestRepo(t)
+	repo := openRepo(t, path)
+	createBranch(t, repo, "feature")
+
+	var buf bytes.Buffer
+	err := runList(&Options{Path: path, Verbose: true, Output: &buf})
+	require.NoError(t, err)
+
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	require.Len(t, lines, 2)
+	assert.Equal(t, ".", string(lines[0]))
+	assert.Regexp(t, `^└── master\*, feature \(.+\)  [0-9a-f]{7}  \d{4}-\d{2}-\d{2} \d{2}:\d{2}  Test User <test@example\.com>  Initial commit$`, string(lines[1]))
+}
+
+func TestRunList_Color(t *testing.T) {
+	path := createTestRepo(t)
+
+	var buf bytes.Buffer
+	err := runList(&Options{Path: path, Color: "never", Output: &buf})
+	require.NoError(t, err)
+	assert.Equal(t, ".\n└── master* (0m ago)\n", buf.String())
+
+	buf.Reset()
+	err = runList(&Options{Path: path, Color: "always", Verbose: true, Output: &buf})
+	require.NoError(t, err)
+	assert.Contains(t, buf.String(), "\x1b[")
+	assert.Contains(t, buf.String(), "master*")
+
+	err = runList(&Options{Path: path, Color: "rainbow", Output: &buf})
+	assert.ErrorIs(t, err, tree.ErrUnknownColorMode)
+}
+
+func TestNewListCommand_ValidatesFlags(t *testing.T) {
+	// the path is not a repository, so only checking the flags first gets
+	// past it to their errors
+	missing := filepath.Join(t.TempDir(), "missing")
+
+	tests := []struct {
+		args []string
+		want error
+	}{
+		{args: []string{"--sort", "size"}, want: tree.ErrUnknownSortOrder},
+		{args: []string{"--infer", "guess"}, want: branchtree.ErrUnknownInference},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.args[0], func(t *testing.T) {
+			cmd := NewListCommand()
+			cmd.SetArgs(append(tt.args, "--path", missing))
+			cmd.SetOut(&bytes.Buffer{})
+			cmd.SetErr(&bytes.Buffer{})
+
+			err := cmd.Execute()
+			assert.ErrorIs(t, err, tt.want)
+		})
+	}
+}
+
 func TestNewListCommand(t *testing.T) {
 	cmd := NewListCommand()
 
@@ -91,6 +272,48 @@ func TestNewListCommand(t *testing.T) {
 	require.NotNil(t, flag)
 	assert.Equal(t, "p", flag.Shorthand)
 	assert.Equal(t, defaultPath, flag.DefValue)
+
+	flag = cmd.Flags().Lookup("remotes")
+	require.NotNil(t, flag)
+	assert.Equal(t, "r", flag.Shorthand)
+
+	flag = cmd.Flags().Lookup("all")
+	require.NotNil(t, flag)
+	assert.Equal(t, "a", flag.Shorthand)
+
+	flag = cmd.Flags().Lookup("infer")
+	require.NotNil(t, flag)
+	assert.Equal(t, "ancestry", flag.DefValue)
+
+	flag = cmd.Flags().Lookup("dag")
+	require.NotNil(t, flag)
+	assert.Equal(t, "false", flag.DefValue)
+
+	flag = cmd.Flags().Lookup("squash-merged")
+	require.NotNil(t, flag)
+	assert.Equal(t, "false", flag.DefValue)
+
+	flag = cmd.Flags().Lookup("sort")
+	require.NotNil(t, flag)
+	assert.Equal(t, "name", flag.DefValue)
+
+	flag = cmd.Flags().Lookup("format")
+	require.NotNil(t, flag)
+	assert.Empty(t, flag.DefValue)
+
+	flag = cmd.Flags().Lookup("verbose")
+	require.NotNil(t, flag)
+	assert.Equal(t, "v", flag.Shorthand)
+	assert.Equal(t, "false", flag.DefValue)
+
+	flag = cmd.Flags().Lookup("color")
+	require.NotNil(t, flag)
+	assert.Equal(t, "auto", flag.DefValue)
+
+	flag = cmd.Flags().Lookup("output")
+	require.NotNil(t, flag)
+	assert.Equal(t, "o", flag.Shorthand)
+	assert.Equal(t, "text", flag.DefValue)
 }
 
 func createTestRepo(t *testing.T) string {
@@ -140,3 +363,28 @@ func createBranch(t *testing.T, repo *git.Repository, name string) {
 	err = repo.Storer.SetReference(ref)
 	require.NoError(t, err)
 }
+
+func addRemote(t *testing.T, repo *git.Repository, name string) {
+	t.Helper()
+
+	remoteDir := t.TempDir()
+	_, err := git.PlainInit(remoteDir, true)
+	require.NoError(t, err)
+
+	_, err = repo.CreateRemote(&config.RemoteConfig{
+		Name: name,
+		URLs: []string{remoteDir},
+	})
+	require.NoError(t, err)
+
+	err = repo.Push(&git.PushOptions{
+		RemoteName: name,
+		RefSpecs:   []config.RefSpec{"refs/heads/*:refs/heads/*"},
+	})
+	require.NoError(t, err)
+
+	err = repo.Fetch(&git.FetchOptions{RemoteName: name})
+	if err != git.NoErrAlreadyUpToDate {
+		require.NoError(t, err)
+	}
+}
@@ -0,0 +1,148 @@
+package prune
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/mucansever/gittree/internal/branchtree"
+	"github.com/mucansever/gittree/internal/git"
+)
+
+const (
+	defaultPath = "."
+)
+
+type Options struct {
+	Path         string
+	Trunk        string
+	Yes          bool
+	DryRun       bool
+	SquashMerged bool
+	Input        io.Reader
+	Output       io.Writer
+}
+
+func NewPruneCommand() *cobra.Command {
+	opts := &Options{
+		Input:  os.Stdin,
+		Output: os.Stdout,
+	}
+
+	cmd := &cobra.Command{
+		Use:   "prune",
+		Short: "Delete local branches already merged into the trunk",
+		Long: `Delete local branches whose tips are fully contained in the trunk.
+The trunk itself and the current branch are never deleted. Branches are
+only deleted after confirmation, unless --yes is given.
+
+With --squash-merged, branches whose changes reached the trunk through a
+squash or rebase merge are deleted as well. These are found by comparing
+patch-ids, since their tips never become part of the trunk.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runPrune(opts)
+		},
+	}
+
+	cmd.Flags().StringVarP(&opts.Path, "path", "p", defaultPath,
+		"Path to the git repository")
+	cmd.Flags().StringVar(&opts.Trunk, "trunk", "",
+		"Branch to check merges against (default main or master)")
+	cmd.Flags().BoolVarP(&opts.Yes, "yes", "y", false,
+		"Delete without asking for confirmation")
+	cmd.Flags().BoolVarP(&opts.DryRun, "dry-run", "n", false,
+		"Only list the branches that would be deleted")
+	cmd.Flags().BoolVar(&opts.SquashMerged, "squash-merged", false,
+		"Also delete branches that were squash- or rebase-merged")
+
+	return cmd
+}
+
+func runPrune(opts *Options) error {
+	repo, err := git.Open(opts.Path)
+	if err != nil {
+		return fmt.Errorf("failed to open repository: %w", err)
+	}
+
+	branches, err := repo.GetBranches()
+	if err != nil {
+		return fmt.Errorf("failed to get branches: %w", err)
+	}
+
+	trunk, err := branchtree.FindTrunk(branches, opts.Trunk)
+	if err != nil {
+		return fmt.Errorf("failed to find trunk: %w", err)
+	}
+
+	merged, err := repo.GetMergedBranches(branches, trunk)
+	if err != nil {
+		return fmt.Errorf("failed to find merged branches: %w", err)
+	}
+
+	if opts.SquashMerged {
+		squashMerged, err := repo.GetSquashMergedBranches(branches, trunk)
+		if err != nil {
+			return fmt.Errorf("failed to find squash-merged branches: %w", err)
+		}
+		for name := range squashMerged {
+			merged[name] = true
+		}
+	}
+
+	currentBranch, err := repo.GetCurrentBranch()
+	if err != nil && err != git.ErrDetachedHead {
+		return fmt.Errorf("failed to get current branch: %w", err)
+	}
+
+	var candidates []string
+	for name := range merged {
+		if name != currentBranch {
+			candidates = append(candidates, name)
+		}
+	}
+	sort.Strings(candidates)
+
+	if len(candidates) == 0 {
+		fmt.Fprintf(opts.Output, "No branches merged into %s\n", trunk.Name)
+		return nil
+	}
+
+	if opts.DryRun {
+		for _, name := range candidates {
+			fmt.Fprintf(opts.Output, "Would delete %s\n", name)
+		}
+		return nil
+	}
+
+	fmt.Fprintf(opts.Output, "Branches merged into %s:\n", trunk.Name)
+	for _, name := range candidates {
+		fmt.Fprintf(opts.Output, "  %s\n", name)
+	}
+
+	if !opts.Yes && !confirm(opts, len(candidates)) {
+		fmt.Fprintln(opts.Output, "Aborted")
+		return nil
+	}
+
+	for _, name := range candidates {
+		if err := repo.DeleteBranch(name); err != nil {
+			return fmt.Errorf("failed to delete branch: %w", err)
+		}
+		fmt.Fprintf(opts.Output, "Deleted %s\n", name)
+	}
+
+	return nil
+}
+
+func confirm(opts *Options, count int) bool {
+	fmt.Fprintf(opts.Output, "Delete %d branches? [y/N] ", count)
+
+	answer, _ := bufio.NewReader(opts.Input).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
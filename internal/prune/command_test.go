@@ -0,0 +1,237 @@
+package prune
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunPrune(t *testing.T) {
+	tests := []struct {
+		name        string
+		yes         bool
+		dryRun      bool
+		input       string
+		wantOutput  []string
+		wantDeleted bool
+	}{
+		{
+			name:        "dry run",
+			dryRun:      true,
+			wantOutput:  []string{"Would delete done", "Would delete old"},
+			wantDeleted: false,
+		},
+		{
+			name:        "confirmed",
+			input:       "y\n",
+			wantOutput:  []string{"Branches merged into master:", "Delete 2 branches? [y/N]", "Deleted done", "Deleted old"},
+			wantDeleted: true,
+		},
+		{
+			name:        "declined",
+			input:       "\n",
+			wantOutput:  []string{"Aborted"},
+			wantDeleted: false,
+		},
+		{
+			name:        "yes flag",
+			yes:         true,
+			wantOutput:  []string{"Deleted done", "Deleted old"},
+			wantDeleted: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := createTestRepo(t)
+			repo := openRepo(t, path)
+
+			createBranch(t, repo, "old")
+			createBranch(t, repo, "done")
+			commitFile(t, repo, "file1.txt", "content1")
+			createBranch(t, repo, "wip")
+			checkoutBranch(t, repo, "wip")
+			commitFile(t, repo, "file2.txt", "content2")
+			checkoutBranch(t, repo, "master")
+
+			var buf bytes.Buffer
+			err := runPrune(&Options{
+				Path:   path,
+				Yes:    tt.yes,
+				DryRun: tt.dryRun,
+				Input:  strings.NewReader(tt.input),
+				Output: &buf,
+			})
+			require.NoError(t, err)
+
+			for _, want := range tt.wantOutput {
+				assert.Contains(t, buf.String(), want)
+			}
+			assert.NotContains(t, buf.String(), "wip")
+
+			assert.Equal(t, !tt.wantDeleted, branchExists(t, repo, "done"))
+			assert.Equal(t, !tt.wantDeleted, branchExists(t, repo, "old"))
+			assert.True(t, branchExists(t, repo, "wip"))
+			assert.True(t, branchExists(t, repo, "master"))
+		})
+	}
+}
+
+func TestRunPrune_KeepsCurrentBranch(t *testing.T) {
+	path := createTestRepo(t)
+	repo := openRepo(t, path)
+
+	createBranch(t, repo, "done")
+	commitFile(t, repo, "file1.txt", "content1")
+	checkoutBranch(t, repo, "done")
+
+	var buf bytes.Buffer
+	err := runPrune(&Options{Path: path, Yes: true, Output: &buf})
+	require.NoError(t, err)
+
+	assert.Contains(t, buf.String(), "No branches merged into master")
+	assert.True(t, branchExists(t, repo, "done"))
+}
+
+func TestRunPrune_SquashMerged(t *testing.T) {
+	path := createTestRepo(t)
+	repo := openRepo(t, path)
+
+	createBranch(t, repo, "squashed")
+	checkoutBranch(t, repo, "squashed")
+	commitFile(t, repo, "file1.txt", "content1")
+	checkoutBranch(t, repo, "master")
+	commitFile(t, repo, "file2.txt", "content2")
+	commitFile(t, repo, "file1.txt", "content1")
+
+	var buf bytes.Buffer
+	err := runPrune(&Options{Path: path, Yes: true, Output: &buf})
+	require.NoError(t, err)
+	assert.True(t, branchExists(t, repo, "squashed"))
+
+	buf.Reset()
+	err = runPrune(&Options{Path: path, Yes: true, SquashMerged: true, Output: &buf})
+	require.NoError(t, err)
+	assert.Contains(t, buf.String(), "Deleted squashed")
+	assert.False(t, branchExists(t, repo, "squashed"))
+}
+
+func TestRunPrune_UnknownTrunk(t *testing.T) {
+	path := createTestRepo(t)
+
+	var buf bytes.Buffer
+	err := runPrune(&Options{Path: path, Trunk: "develop", Output: &buf})
+	assert.Error(t, err)
+}
+
+func TestNewPruneCommand(t *testing.T) {
+	cmd := NewPruneCommand()
+
+	assert.Equal(t, "prune", cmd.Use)
+	assert.NotEmpty(t, cmd.Short)
+	assert.NotEmpty(t, cmd.Long)
+	assert.NotNil(t, cmd.RunE)
+
+	for _, name := range []string{"path", "trunk", "yes", "dry-run", "squash-merged"} {
+		assert.NotNil(t, cmd.Flags().Lookup(name), name)
+	}
+}
+
+func branchExists(t *testing.T, repo *git.Repository, name string) bool {
+	t.Helper()
+
+	_, err := repo.Reference(plumbing.NewBranchReferenceName(name), false)
+	return err == nil
+}
+
+func createTestRepo(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	require.NoError(t, err)
+
+	w, err := repo.Worktree()
+	require.NoError(t, err)
+
+	filename := filepath.Join(dir, "README.md")
+	err = os.WriteFile(filename, []byte("# Test"), 0644)
+	require.NoError(t, err)
+
+	_, err = w.Add("README.md")
+	require.NoError(t, err)
+
+	_, err = w.Commit("Initial commit", &git.CommitOptions{
+		Author: &object.Signature{
+			Name:  "Test User",
+			Email: "test@example.com",
+			When:  time.Now(),
+		},
+	})
+	require.NoError(t, err)
+
+	return dir
+}
+
+func openRepo(t *testing.T, path string) *git.Repository {
+	t.Helper()
+
+	repo, err := git.PlainOpen(path)
+	require.NoError(t, err)
+	return repo
+}
+
+func createBranch(t *testing.T, repo *git.Repository, name string) {
+	t.Helper()
+
+	head, err := repo.Head()
+	require.NoError(t, err)
+
+	ref := plumbing.NewHashReference(plumbing.NewBranchReferenceName(name), head.Hash())
+	err = repo.Storer.SetReference(ref)
+	require.NoError(t, err)
+}
+
+func checkoutBranch(t *testing.T, repo *git.Repository, name string) {
+	t.Helper()
+
+	w, err := repo.Worktree()
+	require.NoError(t, err)
+
+	err = w.Checkout(&git.CheckoutOptions{
+		Branch: plumbing.NewBranchReferenceName(name),
+	})
+	require.NoError(t, err)
+}
+
+func commitFile(t *testing.T, repo *git.Repository, filename, content string) {
+	t.Helper()
+
+	w, err := repo.Worktree()
+	require.NoError(t, err)
+
+	path := filepath.Join(w.Filesystem.Root(), filename)
+	err = os.WriteFile(path, []byte(content), 0644)
+	require.NoError(t, err)
+
+	_, err = w.Add(filename)
+	require.NoError(t, err)
+
+	_, err = w.Commit("Add "+filename, &git.CommitOptions{
+		Author: &object.Signature{
+			Name:  "Test User",
+			Email: "test@example.com",
+			When:  time.Now(),
+		},
+	})
+	require.NoError(t, err)
+}
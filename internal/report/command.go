@@ -0,0 +1,127 @@
+package report
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/mucansever/gittree/internal/branchtree"
+	"github.com/mucansever/gittree/internal/git"
+)
+
+const (
+	defaultPath = "."
+	// writes the report to standard output instead of a file
+	stdoutPath = "-"
+)
+
+var (
+	ErrMissingHTMLPath = errors.New("--html is required")
+)
+
+type Options struct {
+	Path         string
+	Remotes      bool
+	All          bool
+	Infer        string
+	Trunk        string
+	SquashMerged bool
+	HTML         string
+	Output       io.Writer
+}
+
+func NewReportCommand() *cobra.Command {
+	opts := &Options{
+		Output: os.Stdout,
+	}
+
+	cmd := &cobra.Command{
+		Use:   "report",
+		Short: "Write the branch tree as a self-contained HTML page",
+		Long: `Write the branch tree as a single HTML file that works offline, for
+sharing what is in flight with people who do not use a terminal. Every
+branch can be folded away and shows its last commit, author, commits
+ahead of and behind its parent, and whether it was merged. A search box
+narrows the tree down to matching branches.
+
+The tree is the same one "gittree list" prints, and takes the same
+--remotes, --all, --infer, --trunk and --squash-merged flags. Use --html - to write the page
+to standard output.`,
+		// a bad --infer is reported before the repository is opened
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			return branchtree.Inference(opts.Infer).Validate()
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runReport(opts)
+		},
+	}
+
+	cmd.Flags().StringVarP(&opts.Path, "path", "p", defaultPath,
+		"Path to the git repository")
+	cmd.Flags().BoolVarP(&opts.Remotes, "remotes", "r", false,
+		"Report remote-tracking branches only")
+	cmd.Flags().BoolVarP(&opts.All, "all", "a", false,
+		"Report both local and remote-tracking branches")
+	cmd.Flags().StringVar(&opts.Infer, "infer", string(branchtree.InferAncestry),
+		"How branch parents are inferred: ancestry, fork-point or reflog")
+	cmd.Flags().StringVar(&opts.Trunk, "trunk", "",
+		"Branch that merged branches are detected against (default main or master)")
+	cmd.Flags().BoolVar(&opts.SquashMerged, "squash-merged", false,
+		"Tag branches that were squash- or rebase-merged into the trunk")
+	cmd.Flags().StringVar(&opts.HTML, "html", "",
+		"File to write the report to")
+
+	return cmd
+}
+
+func runReport(opts *Options) error {
+	if opts.HTML == "" {
+		return ErrMissingHTMLPath
+	}
+
+	repo, err := git.Open(opts.Path)
+	if err != nil {
+		return fmt.Errorf("failed to open repository: %w", err)
+	}
+
+	t, err := branchtree.Load(repo, branchtree.Options{
+		Remotes:      opts.Remotes,
+		All:          opts.All,
+		Inference:    branchtree.Inference(opts.Infer),
+		Trunk:        opts.Trunk,
+		SquashMerged: opts.SquashMerged,
+	})
+	if err != nil && !errors.Is(err, branchtree.ErrNoBranches) {
+		return err
+	}
+
+	title := filepath.Base(opts.Path)
+	if abs, err := filepath.Abs(opts.Path); err == nil {
+		title = filepath.Base(abs)
+	}
+	data := newPage(title, t, time.Now())
+
+	if opts.HTML == stdoutPath {
+		return writeHTML(opts.Output, data)
+	}
+
+	f, err := os.Create(opts.HTML)
+	if err != nil {
+		return fmt.Errorf("failed to create report: %w", err)
+	}
+	if err := writeHTML(f, data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+
+	fmt.Fprintf(opts.Output, "Wrote %s\n", opts.HTML)
+	return nil
+}
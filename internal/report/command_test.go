@@ -0,0 +1,195 @@
+package report
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mucansever/gittree/internal/tree"
+)
+
+func TestRunReport(t *testing.T) {
+	path := createTestRepo(t)
+	repo := openRepo(t, path)
+
+	createBranch(t, repo, "feature")
+	checkoutBranch(t, repo, "feature")
+	commitFile(t, repo, "feature.txt", "feature")
+	createBranch(t, repo, "done")
+	checkoutBranch(t, repo, "master")
+
+	out := filepath.Join(t.TempDir(), "report.html")
+	var buf bytes.Buffer
+	err := runReport(&Options{Path: path, HTML: out, Output: &buf})
+	require.NoError(t, err)
+	assert.Equal(t, "Wrote "+out+"\n", buf.String())
+
+	data, err := os.ReadFile(out)
+	require.NoError(t, err)
+	html := string(data)
+
+	assert.True(t, strings.HasPrefix(html, "<!DOCTYPE html>"))
+	assert.Contains(t, html, `<span class="name">master</span>`)
+	assert.Contains(t, html, `<li data-search="master" class="current ">`)
+	assert.Contains(t, html, `<span class="name">done</span><span class="tag">feature</span>`)
+	assert.Contains(t, html, "Add feature.txt")
+	assert.Contains(t, html, "Test User &lt;test@example.com&gt;")
+	assert.Contains(t, html, "<span>+1 -0</span>")
+	assert.Contains(t, html, `id="search"`)
+	// everything the page needs is inline
+	assert.NotContains(t, html, "<link")
+	assert.NotContains(t, html, "src=")
+}
+
+func TestRunReport_Stdout(t *testing.T) {
+	path := createTestRepo(t)
+
+	var buf bytes.Buffer
+	err := runReport(&Options{Path: path, HTML: "-", Output: &buf})
+	require.NoError(t, err)
+	assert.Contains(t, buf.String(), `<span class="name">master</span>`)
+}
+
+func TestRunReport_MissingHTMLPath(t *testing.T) {
+	err := runReport(&Options{Path: createTestRepo(t)})
+	assert.ErrorIs(t, err, ErrMissingHTMLPath)
+}
+
+func TestNewPage(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	tr := &tree.Tree{Root: &tree.Node{Name: ".", Children: []*tree.Node{
+		{Name: "main", Children: []*tree.Node{
+			{Name: "feat/a", Aliases: []string{"feat/b"}, IsCurrent: true, CurrentAlias: "feat/b", SquashMerged: true},
+		}},
+	}}}
+
+	p := newPage("repo", tr, now)
+
+	assert.Equal(t, "repo", p.Title)
+	assert.Equal(t, "2024-05-01 12:00 UTC", p.Generated)
+	assert.Equal(t, 2, p.Branches)
+	require.Len(t, p.Roots, 1)
+	require.Len(t, p.Roots[0].Children, 1)
+
+	a := p.Roots[0].Children[0]
+	assert.True(t, a.Current)
+	assert.Equal(t, []string{"feat/b"}, a.Aliases)
+	assert.Equal(t, "squash-merged", a.Status)
+	assert.Equal(t, "feat/a feat/b", a.Search)
+}
+
+func TestNewPage_DetachedHead(t *testing.T) {
+	tr := &tree.Tree{Root: &tree.Node{Name: ".", Children: []*tree.Node{
+		{Name: "HEAD", IsDetached: true, Hash: "abc1234def5678"},
+	}}}
+
+	p := newPage("repo", tr, time.Now())
+
+	require.Len(t, p.Roots, 1)
+	assert.Equal(t, "HEAD (detached at abc1234)", p.Roots[0].Name)
+}
+
+func TestNewReportCommand(t *testing.T) {
+	cmd := NewReportCommand()
+
+	assert.Equal(t, "report", cmd.Use)
+	assert.NotEmpty(t, cmd.Short)
+	assert.NotEmpty(t, cmd.Long)
+	assert.NotNil(t, cmd.RunE)
+
+	for _, name := range []string{"path", "remotes", "all", "infer", "trunk", "squash-merged", "html"} {
+		assert.NotNil(t, cmd.Flags().Lookup(name), name)
+	}
+}
+
+func createTestRepo(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	require.NoError(t, err)
+
+	w, err := repo.Worktree()
+	require.NoError(t, err)
+
+	filename := filepath.Join(dir, "README.md")
+	err = os.WriteFile(filename, []byte("# Test"), 0644)
+	require.NoError(t, err)
+
+	_, err = w.Add("README.md")
+	require.NoError(t, err)
+
+	_, err = w.Commit("Initial commit", &git.CommitOptions{
+		Author: &object.Signature{
+			Name:  "Test User",
+			Email: "test@example.com",
+			When:  time.Now(),
+		},
+	})
+	require.NoError(t, err)
+
+	return dir
+}
+
+func openRepo(t *testing.T, path string) *git.Repository {
+	t.Helper()
+
+	repo, err := git.PlainOpen(path)
+	require.NoError(t, err)
+	return repo
+}
+
+func createBranch(t *testing.T, repo *git.Repository, name string) {
+	t.Helper()
+
+	head, err := repo.Head()
+	require.NoError(t, err)
+
+	ref := plumbing.NewHashReference(plumbing.NewBranchReferenceName(name), head.Hash())
+	err = repo.Storer.SetReference(ref)
+	require.NoError(t, err)
+}
+
+func checkoutBranch(t *testing.T, repo *git.Repository, name string) {
+	t.Helper()
+
+	w, err := repo.Worktree()
+	require.NoError(t, err)
+
+	err = w.Checkout(&git.CheckoutOptions{
+		Branch: plumbing.NewBranchReferenceName(name),
+	})
+	require.NoError(t, err)
+}
+
+func commitFile(t *testing.T, repo *git.Repository, filename, content string) {
+	t.Helper()
+
+	w, err := repo.Worktree()
+	require.NoError(t, err)
+
+	path := filepath.Join(w.Filesystem.Root(), filename)
+	err = os.WriteFile(path, []byte(content), 0644)
+	require.NoError(t, err)
+
+	_, err = w.Add(filename)
+	require.NoError(t, err)
+
+	_, err = w.Commit("Add "+filename, &git.CommitOptions{
+		Author: &object.Signature{
+			Name:  "Test User",
+			Email: "test@example.com",
+			When:  time.Now(),
+		},
+	})
+	require.NoError(t, err)
+}
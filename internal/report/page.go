@@ -0,0 +1,116 @@
+package report
+
+import (
+	_ "embed"
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/mucansever/gittree/internal/timefmt"
+	"github.com/mucansever/gittree/internal/tree"
+)
+
+//go:embed report.html
+var pageSource string
+
+var pageTemplate = template.Must(template.New("report").Parse(pageSource))
+
+// data rendered by report.html
+type page struct {
+	Title     string
+	Generated string
+	Branches  int
+	Roots     []pageNode
+}
+
+// a branch on the page
+type pageNode struct {
+	Name    string
+	Aliases []string
+	Current bool
+	Hash    string
+	Subject string
+	Author  string
+	// relative and absolute time of the tip commit
+	Age        string
+	LastCommit string
+	Ahead      int
+	Behind     int
+	// "merged", "squash-merged" or empty
+	Status   string
+	Recorded bool
+	AlsoFrom []string
+	Children []pageNode
+	// lowercase names of the branch and its aliases, matched by the search box
+	Search string
+}
+
+func newPage(title string, t *tree.Tree, now time.Time) page {
+	p := page{
+		Title:     title,
+		Generated: now.Format("2006-01-02 15:04 MST"),
+	}
+	if t == nil || t.Root == nil {
+		return p
+	}
+
+	roots := t.Root.Children
+	if t.Root.Name != "." && t.Root.Name != "" {
+		roots = []*tree.Node{t.Root}
+	}
+	for _, root := range roots {
+		p.Roots = append(p.Roots, newPageNode(root, &p.Branches))
+	}
+	return p
+}
+
+func newPageNode(node *tree.Node, count *int) pageNode {
+	*count++
+
+	name := node.Name
+	if node.IsDetached {
+		name = fmt.Sprintf("HEAD (detached at %s)", node.ShortHash())
+	}
+
+	out := pageNode{
+		Name:     name,
+		Aliases:  node.Aliases,
+		Current:  node.IsCurrent,
+		Ahead:    node.Ahead,
+		Behind:   node.Behind,
+		Recorded: node.Recorded,
+		AlsoFrom: node.AlsoFrom,
+		Search:   strings.ToLower(strings.Join(append([]string{name}, node.Aliases...), " ")),
+	}
+
+	if !node.LastCommit.IsZero() {
+		out.Age = timefmt.RelativeTime(node.LastCommit) + " ago"
+		out.LastCommit = node.LastCommit.Format("2006-01-02 15:04")
+	}
+	switch {
+	case node.Merged:
+		out.Status = "merged"
+	case node.SquashMerged:
+		out.Status = "squash-merged"
+	}
+
+	out.Hash = node.ShortHash()
+	out.Subject = node.Subject
+	if node.Author != "" {
+		out.Author = fmt.Sprintf("%s <%s>", node.Author, node.AuthorEmail)
+	}
+
+	for _, child := range node.Children {
+		out.Children = append(out.Children, newPageNode(child, count))
+	}
+	return out
+}
+
+func writeHTML(w io.Writer, p page) error {
+	if err := pageTemplate.Execute(w, p); err != nil {
+		return fmt.Errorf("failed to render report: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,383 @@
+package restack
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/spf13/cobra"
+
+	"github.com/mucansever/gittree/internal/branchtree"
+	"github.com/mucansever/gittree/internal/git"
+	"github.com/mucansever/gittree/internal/tree"
+)
+
+const (
+	defaultPath = "."
+)
+
+var (
+	ErrInProgress     = errors.New("a restack is already in progress, use --continue or --abort")
+	ErrNotInProgress  = errors.New("no restack in progress")
+	ErrBranchNotFound = errors.New("branch not found in the tree")
+	ErrLocalChanges   = errors.New("the worktree has uncommitted changes, commit or stash them first")
+)
+
+type Options struct {
+	Path     string
+	Trunk    string
+	Continue bool
+	Abort    bool
+	Output   io.Writer
+}
+
+func NewRestackCommand() *cobra.Command {
+	opts := &Options{
+		Output: os.Stdout,
+	}
+
+	cmd := &cobra.Command{
+		Use:   "restack [branch]",
+		Short: "Rebase child branches onto their updated parents",
+		Long: `Rebase every branch below the given branch (default the current branch)
+onto the new tip of its parent in the tree, parents before their children.
+Only the commits a branch added on top of its parent's old tip are replayed,
+so amending or rebasing a branch low in a stack can be carried up the stack.
+
+The worktree must have no uncommitted changes. When a rebase stops on a
+conflict, resolve it and run restack --continue, or run restack --abort to
+put every branch back where it was.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			branch := ""
+			if len(args) > 0 {
+				branch = args[0]
+			}
+			return runRestack(opts, branch)
+		},
+	}
+
+	cmd.Flags().StringVarP(&opts.Path, "path", "p", defaultPath,
+		"Path to the git repository")
+	cmd.Flags().StringVar(&opts.Trunk, "trunk", "",
+		"Branch kept at the top of the tree (default main or master)")
+	cmd.Flags().BoolVar(&opts.Continue, "continue", false,
+		"Continue after resolving a conflict")
+	cmd.Flags().BoolVar(&opts.Abort, "abort", false,
+		"Abort and restore all branches")
+	cmd.MarkFlagsMutuallyExclusive("continue", "abort")
+
+	return cmd
+}
+
+func runRestack(opts *Options, branch string) error {
+	repo, err := git.Open(opts.Path)
+	if err != nil {
+		return fmt.Errorf("failed to open repository: %w", err)
+	}
+
+	gitDir, err := repo.GitDir()
+	if err != nil {
+		return err
+	}
+
+	s, err := loadState(gitDir)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case opts.Continue:
+		if s == nil {
+			return ErrNotInProgress
+		}
+		return continueRestack(opts, repo, gitDir, s)
+
+	case opts.Abort:
+		if s == nil {
+			return ErrNotInProgress
+		}
+		return abortRestack(opts, repo, gitDir, s)
+
+	case s != nil:
+		return ErrInProgress
+	}
+
+	original, err := repo.GetCurrentBranch()
+	if err != nil && !errors.Is(err, git.ErrDetachedHead) {
+		return fmt.Errorf("failed to get current branch: %w", err)
+	}
+	if branch == "" {
+		if original == "" {
+			return errors.New("HEAD is detached, name the branch to restack")
+		}
+		branch = original
+	}
+
+	t, err := branchtree.Load(repo, branchtree.Options{
+		Inference: branchtree.InferForkPoint,
+		Trunk:     opts.Trunk,
+	})
+	if err != nil {
+		return err
+	}
+
+	steps, err := collectSteps(t, branch)
+	if err != nil {
+		return err
+	}
+	if len(steps) == 0 {
+		fmt.Fprintf(opts.Output, "Nothing to restack below %s\n", branch)
+		return nil
+	}
+
+	dirty, err := repo.HasLocalChanges()
+	if err != nil {
+		return err
+	}
+	if dirty {
+		return ErrLocalChanges
+	}
+
+	s = &state{
+		Original: original,
+		Steps:    steps,
+		OldTips:  make(map[string]string),
+	}
+	return runSteps(opts, repo, gitDir, s)
+}
+
+// returns a step for every branch below the named one, parents first
+func collectSteps(t *tree.Tree, branch string) ([]step, error) {
+	var start *tree.Node
+	t.Walk(func(node *tree.Node) {
+		for _, name := range append([]string{node.Name}, node.Aliases...) {
+			if name == branch {
+				start = node
+			}
+		}
+	})
+	if start == nil {
+		return nil, fmt.Errorf("%w: %s", ErrBranchNotFound, branch)
+	}
+
+	var steps []step
+	var walk func(parent *tree.Node)
+	walk = func(parent *tree.Node) {
+		for _, child := range parent.Children {
+			// a detached HEAD is not a branch that can be rebased
+			if child.IsDetached {
+				continue
+			}
+			steps = append(steps, step{
+				Branch: child.Name,
+				Parent: parent.Name,
+			})
+			for _, alias := range child.Aliases {
+				steps = append(steps, step{
+					Branch: alias,
+					Parent: parent.Name,
+					Follow: child.Name,
+				})
+			}
+			walk(child)
+		}
+	}
+	walk(start)
+
+	return steps, nil
+}
+
+func runSteps(opts *Options, repo *git.Repository, gitDir string, s *state) error {
+	for len(s.Steps) > 0 {
+		current := s.Steps[0]
+
+		parent, err := repo.GetBranch(current.Parent)
+		if err != nil {
+			return err
+		}
+		branch, err := repo.GetBranch(current.Branch)
+		if err != nil {
+			return err
+		}
+
+		if current.Follow != "" {
+			if err := followStep(opts, repo, gitDir, s, branch); err != nil {
+				return giveUp(repo, gitDir, s, err)
+			}
+			s.Steps = s.Steps[1:]
+			continue
+		}
+
+		upToDate, err := repo.IsAncestor(parent.Hash, branch.Hash)
+		if err != nil {
+			return err
+		}
+		if upToDate {
+			fmt.Fprintf(opts.Output, "%s is already on %s\n", current.Branch, current.Parent)
+			s.Steps = s.Steps[1:]
+			continue
+		}
+
+		upstream, err := upstreamOf(repo, s, parent, branch)
+		if err != nil {
+			return err
+		}
+
+		s.OldTips[current.Branch] = branch.Hash.String()
+		if err := saveState(gitDir, s); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(opts.Output, "Restacking %s onto %s\n", current.Branch, current.Parent)
+		err = repo.Rebase(parent.Hash, upstream, current.Branch)
+		if errors.Is(err, git.ErrRebaseConflict) {
+			fmt.Fprintf(opts.Output, "Conflict while restacking %s. Resolve it, stage the changes and run\n", current.Branch)
+			fmt.Fprintln(opts.Output, "  gittree restack --continue")
+			fmt.Fprintln(opts.Output, "or put all branches back with")
+			fmt.Fprintln(opts.Output, "  gittree restack --abort")
+			return err
+		}
+		if err != nil {
+			return giveUp(repo, gitDir, s, err)
+		}
+
+		s.Steps = s.Steps[1:]
+		if err := saveState(gitDir, s); err != nil {
+			return err
+		}
+	}
+
+	if s.Original != "" {
+		if err := repo.Checkout(s.Original); err != nil {
+			return err
+		}
+	}
+	if err := removeState(gitDir); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(opts.Output, "Restack complete")
+	return nil
+}
+
+// puts back the branches moved so far after a step failed with err other
+// than a conflict, which leaves nothing to continue, and returns err
+func giveUp(repo *git.Repository, gitDir string, s *state, err error) error {
+	if restoreErr := restoreBranches(repo, gitDir, s); restoreErr != nil {
+		return fmt.Errorf("%w (restoring branches: %v)", err, restoreErr)
+	}
+	return err
+}
+
+// moves a branch that shared its tip with another one to that branch's tip
+func followStep(opts *Options, repo *git.Repository, gitDir string, s *state, branch git.Branch) error {
+	current := s.Steps[0]
+
+	target, err := repo.GetBranch(current.Follow)
+	if err != nil {
+		return err
+	}
+	if target.Hash == branch.Hash {
+		fmt.Fprintf(opts.Output, "%s is already on %s\n", current.Branch, current.Parent)
+		return nil
+	}
+
+	s.OldTips[current.Branch] = branch.Hash.String()
+	if err := saveState(gitDir, s); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(opts.Output, "Moving %s along with %s\n", current.Branch, current.Follow)
+	return repo.ResetBranch(current.Branch, target.Hash)
+}
+
+// Returns the commit below which the commits of branch belong to parent.
+// If parent was rebased earlier in this restack, that is parent's old tip;
+// otherwise it is the fork point of branch from parent.
+func upstreamOf(repo *git.Repository, s *state, parent, branch git.Branch) (plumbing.Hash, error) {
+	if old, ok := s.OldTips[parent.Name]; ok {
+		oldTip := plumbing.NewHash(old)
+		onOldTip, err := repo.IsAncestor(oldTip, branch.Hash)
+		if err != nil {
+			return plumbing.ZeroHash, err
+		}
+		if onOldTip {
+			return oldTip, nil
+		}
+	}
+	return repo.ForkBase(parent, branch)
+}
+
+func continueRestack(opts *Options, repo *git.Repository, gitDir string, s *state) error {
+	if repo.RebaseInProgress() {
+		err := repo.ContinueRebase()
+		if errors.Is(err, git.ErrRebaseConflict) {
+			fmt.Fprintln(opts.Output, "The rebase still has conflicts, resolve them and run gittree restack --continue")
+			return err
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	// the step that stopped is done, unless its rebase never got going or
+	// was aborted by hand, in which case it is run again
+	if len(s.Steps) > 0 {
+		current := s.Steps[0]
+		branch, err := repo.GetBranch(current.Branch)
+		if err != nil {
+			return err
+		}
+		if branch.Hash.String() != s.OldTips[current.Branch] {
+			s.Steps = s.Steps[1:]
+		}
+	}
+	return runSteps(opts, repo, gitDir, s)
+}
+
+func abortRestack(opts *Options, repo *git.Repository, gitDir string, s *state) error {
+	if repo.RebaseInProgress() {
+		if err := repo.AbortRebase(); err != nil {
+			return err
+		}
+	}
+	if err := restoreBranches(repo, gitDir, s); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(opts.Output, "Restack aborted, branches restored")
+	return nil
+}
+
+// moves every branch back to its tip from before the restack, checks out
+// the original branch again and forgets the restack
+func restoreBranches(repo *git.Repository, gitDir string, s *state) error {
+	if len(s.OldTips) > 0 {
+		if err := repo.DetachHead(); err != nil {
+			return err
+		}
+		for name, hash := range s.OldTips {
+			// the step that failed may not have moved its branch
+			branch, err := repo.GetBranch(name)
+			if err != nil {
+				return err
+			}
+			if branch.Hash.String() == hash {
+				continue
+			}
+			if err := repo.ResetBranch(name, plumbing.NewHash(hash)); err != nil {
+				return err
+			}
+		}
+	}
+
+	if s.Original != "" {
+		if err := repo.Checkout(s.Original); err != nil {
+			return err
+		}
+	}
+	return removeState(gitDir)
+}
@@ -0,0 +1,448 @@
+package restack
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	gittree "github.com/mucansever/gittree/internal/git"
+)
+
+func TestRunRestack(t *testing.T) {
+	path := createTestRepo(t)
+	repo := openRepo(t, path)
+
+	// master -> a -> b -> c, then a moves on
+	createBranch(t, repo, "a")
+	checkoutBranch(t, repo, "a")
+	commitFile(t, repo, "a1.txt", "a1")
+	createBranch(t, repo, "b")
+	checkoutBranch(t, repo, "b")
+	commitFile(t, repo, "b1.txt", "b1")
+	createBranch(t, repo, "c")
+	checkoutBranch(t, repo, "c")
+	commitFile(t, repo, "c1.txt", "c1")
+	checkoutBranch(t, repo, "a")
+	commitFile(t, repo, "a2.txt", "a2")
+
+	var buf bytes.Buffer
+	err := runRestack(&Options{Path: path, Output: &buf}, "")
+	require.NoError(t, err)
+
+	assert.Contains(t, buf.String(), "Restacking b onto a")
+	assert.Contains(t, buf.String(), "Restacking c onto b")
+	assert.Contains(t, buf.String(), "Restack complete")
+
+	repo = openRepo(t, path)
+	a := branchCommit(t, repo, "a")
+	b := branchCommit(t, repo, "b")
+	c := branchCommit(t, repo, "c")
+	assert.Equal(t, []plumbing.Hash{a.Hash}, b.ParentHashes)
+	assert.Equal(t, []plumbing.Hash{b.Hash}, c.ParentHashes)
+	assert.Equal(t, "Add b1.txt\n", b.Message)
+	assert.Equal(t, "Add c1.txt\n", c.Message)
+
+	head, err := repo.Head()
+	require.NoError(t, err)
+	assert.Equal(t, plumbing.NewBranchReferenceName("a"), head.Name())
+	assert.NoFileExists(t, filepath.Join(path, ".git", stateFile))
+
+	// running again finds everything in place
+	buf.Reset()
+	err = runRestack(&Options{Path: path, Output: &buf}, "a")
+	require.NoError(t, err)
+	assert.Contains(t, buf.String(), "b is already on a")
+	assert.Contains(t, buf.String(), "c is already on b")
+}
+
+func TestRunRestack_RewrittenParent(t *testing.T) {
+	tests := []struct {
+		name    string
+		rewrite func(t *testing.T, repo *git.Repository)
+	}{
+		{
+			name: "amended",
+			rewrite: func(t *testing.T, repo *git.Repository) {
+				runGitAt(t, worktreeRoot(t, repo), "commit", "-q", "--amend", "-m", "Add a1.txt, amended")
+			},
+		},
+		{
+			name: "rebased onto the trunk",
+			rewrite: func(t *testing.T, repo *git.Repository) {
+				checkoutBranch(t, repo, "master")
+				commitFile(t, repo, "m1.txt", "m1")
+				checkoutBranch(t, repo, "a")
+				runGitAt(t, worktreeRoot(t, repo), "rebase", "-q", "master")
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := createTestRepo(t)
+			repo := openRepo(t, path)
+
+			// master -> a -> b, then a is rewritten without telling gittree
+			createBranch(t, repo, "a")
+			checkoutBranch(t, repo, "a")
+			commitFile(t, repo, "a1.txt", "a1")
+			createBranch(t, repo, "b")
+			checkoutBranch(t, repo, "b")
+			commitFile(t, repo, "b1.txt", "b1")
+			checkoutBranch(t, repo, "a")
+			tt.rewrite(t, repo)
+
+			var buf bytes.Buffer
+			err := runRestack(&Options{Path: path, Output: &buf}, "a")
+			require.NoError(t, err)
+			assert.Contains(t, buf.String(), "Restacking b onto a")
+
+			repo = openRepo(t, path)
+			a := branchCommit(t, repo, "a")
+			b := branchCommit(t, repo, "b")
+			assert.Equal(t, []plumbing.Hash{a.Hash}, b.ParentHashes)
+			assert.Equal(t, "Add b1.txt\n", b.Message)
+		})
+	}
+}
+
+func TestRunRestack_LeavesParentAlone(t *testing.T) {
+	path := createTestRepo(t)
+	repo := openRepo(t, path)
+
+	// master -> a -> b, then a moves on
+	createBranch(t, repo, "a")
+	checkoutBranch(t, repo, "a")
+	commitFile(t, repo, "a1.txt", "a1")
+	createBranch(t, repo, "b")
+	checkoutBranch(t, repo, "b")
+	commitFile(t, repo, "b1.txt", "b1")
+	checkoutBranch(t, repo, "a")
+	commitFile(t, repo, "a2.txt", "a2")
+	oldA := branchCommit(t, repo, "a").Hash
+	oldB := branchCommit(t, repo, "b").Hash
+
+	var buf bytes.Buffer
+	err := runRestack(&Options{Path: path, Output: &buf}, "b")
+	require.NoError(t, err)
+	assert.Contains(t, buf.String(), "Nothing to restack below b")
+
+	repo = openRepo(t, path)
+	assert.Equal(t, oldA, branchCommit(t, repo, "a").Hash)
+	assert.Equal(t, oldB, branchCommit(t, repo, "b").Hash)
+}
+
+func TestRunRestack_Aliases(t *testing.T) {
+	path := createTestRepo(t)
+	repo := openRepo(t, path)
+
+	createBranch(t, repo, "a")
+	checkoutBranch(t, repo, "a")
+	commitFile(t, repo, "a1.txt", "a1")
+	createBranch(t, repo, "b")
+	checkoutBranch(t, repo, "b")
+	commitFile(t, repo, "b1.txt", "b1")
+	createBranch(t, repo, "b-copy")
+	checkoutBranch(t, repo, "a")
+	commitFile(t, repo, "a2.txt", "a2")
+
+	var buf bytes.Buffer
+	err := runRestack(&Options{Path: path, Output: &buf}, "a")
+	require.NoError(t, err)
+	assert.Contains(t, buf.String(), "Moving b-copy along with b")
+
+	repo = openRepo(t, path)
+	a := branchCommit(t, repo, "a")
+	b := branchCommit(t, repo, "b")
+	assert.Equal(t, []plumbing.Hash{a.Hash}, b.ParentHashes)
+	assert.Equal(t, b.Hash, branchCommit(t, repo, "b-copy").Hash)
+}
+
+func TestRunRestack_Conflict(t *testing.T) {
+	path := createTestRepo(t)
+	repo := openRepo(t, path)
+
+	createBranch(t, repo, "a")
+	checkoutBranch(t, repo, "a")
+	commitFile(t, repo, "file.txt", "a\n")
+	createBranch(t, repo, "b")
+	checkoutBranch(t, repo, "b")
+	commitFile(t, repo, "file.txt", "b\n")
+	checkoutBranch(t, repo, "a")
+	commitFile(t, repo, "file.txt", "a2\n")
+	oldB := branchCommit(t, repo, "b").Hash
+
+	var buf bytes.Buffer
+	err := runRestack(&Options{Path: path, Output: &buf}, "a")
+	assert.ErrorIs(t, err, gittree.ErrRebaseConflict)
+	assert.Contains(t, buf.String(), "gittree restack --continue")
+	assert.FileExists(t, filepath.Join(path, ".git", stateFile))
+
+	err = runRestack(&Options{Path: path, Output: &buf}, "a")
+	assert.ErrorIs(t, err, ErrInProgress)
+
+	// abort puts b back
+	buf.Reset()
+	err = runRestack(&Options{Path: path, Abort: true, Output: &buf}, "")
+	require.NoError(t, err)
+	assert.Contains(t, buf.String(), "Restack aborted")
+	assert.Equal(t, oldB, branchCommit(t, openRepo(t, path), "b").Hash)
+	assert.NoFileExists(t, filepath.Join(path, ".git", stateFile))
+
+	// resolve the conflict and continue
+	err = runRestack(&Options{Path: path, Output: &buf}, "a")
+	assert.ErrorIs(t, err, gittree.ErrRebaseConflict)
+
+	require.NoError(t, os.WriteFile(filepath.Join(path, "file.txt"), []byte("b\n"), 0644))
+	runGit(t, path, "add", "file.txt")
+
+	buf.Reset()
+	err = runRestack(&Options{Path: path, Continue: true, Output: &buf}, "")
+	require.NoError(t, err)
+	assert.Contains(t, buf.String(), "Restack complete")
+
+	repo = openRepo(t, path)
+	a := branchCommit(t, repo, "a")
+	b := branchCommit(t, repo, "b")
+	assert.Equal(t, []plumbing.Hash{a.Hash}, b.ParentHashes)
+
+	head, err := repo.Head()
+	require.NoError(t, err)
+	assert.Equal(t, plumbing.NewBranchReferenceName("a"), head.Name())
+}
+
+func TestRunRestack_LocalChanges(t *testing.T) {
+	path := createTestRepo(t)
+	repo := openRepo(t, path)
+
+	createBranch(t, repo, "a")
+	checkoutBranch(t, repo, "a")
+	commitFile(t, repo, "a1.txt", "a1")
+	createBranch(t, repo, "b")
+	checkoutBranch(t, repo, "b")
+	commitFile(t, repo, "b1.txt", "b1")
+	checkoutBranch(t, repo, "a")
+	commitFile(t, repo, "a2.txt", "a2")
+	oldB := branchCommit(t, repo, "b").Hash
+
+	require.NoError(t, os.WriteFile(filepath.Join(path, "a1.txt"), []byte("edited"), 0644))
+
+	var buf bytes.Buffer
+	err := runRestack(&Options{Path: path, Output: &buf}, "a")
+	assert.ErrorIs(t, err, ErrLocalChanges)
+	assert.NoFileExists(t, filepath.Join(path, ".git", stateFile))
+	assert.Equal(t, oldB, branchCommit(t, openRepo(t, path), "b").Hash)
+
+	// once the changes are gone the restack runs
+	runGit(t, path, "checkout", "--", "a1.txt")
+	err = runRestack(&Options{Path: path, Output: &buf}, "a")
+	require.NoError(t, err)
+}
+
+func TestRunRestack_FailureRestoresBranches(t *testing.T) {
+	path := createTestRepo(t)
+	repo := openRepo(t, path)
+
+	// master -> a -> b -> c, then a moves on
+	createBranch(t, repo, "a")
+	checkoutBranch(t, repo, "a")
+	commitFile(t, repo, "a1.txt", "a1")
+	createBranch(t, repo, "b")
+	checkoutBranch(t, repo, "b")
+	commitFile(t, repo, "b1.txt", "b1")
+	createBranch(t, repo, "c")
+	checkoutBranch(t, repo, "c")
+	commitFile(t, repo, "c1.txt", "c1")
+	checkoutBranch(t, repo, "a")
+	commitFile(t, repo, "a2.txt", "a2")
+	oldB := branchCommit(t, repo, "b").Hash
+	oldC := branchCommit(t, repo, "c").Hash
+
+	// c checked out in another worktree stops its rebase before it starts,
+	// after b was already rebased
+	other := filepath.Join(t.TempDir(), "other")
+	runGit(t, path, "worktree", "add", "-q", other, "c")
+
+	var buf bytes.Buffer
+	err := runRestack(&Options{Path: path, Output: &buf}, "a")
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, gittree.ErrRebaseConflict)
+	assert.Contains(t, buf.String(), "Restacking b onto a")
+
+	assert.NoFileExists(t, filepath.Join(path, ".git", stateFile))
+	repo = openRepo(t, path)
+	assert.Equal(t, oldB, branchCommit(t, repo, "b").Hash)
+	assert.Equal(t, oldC, branchCommit(t, repo, "c").Hash)
+	head, err := repo.Head()
+	require.NoError(t, err)
+	assert.Equal(t, plumbing.NewBranchReferenceName("a"), head.Name())
+
+	// a later restack is not blocked
+	runGit(t, path, "worktree", "remove", other)
+	err = runRestack(&Options{Path: path, Output: &buf}, "a")
+	require.NoError(t, err)
+}
+
+func TestRunRestack_NotInProgress(t *testing.T) {
+	path := createTestRepo(t)
+
+	var buf bytes.Buffer
+	err := runRestack(&Options{Path: path, Continue: true, Output: &buf}, "")
+	assert.ErrorIs(t, err, ErrNotInProgress)
+
+	err = runRestack(&Options{Path: path, Abort: true, Output: &buf}, "")
+	assert.ErrorIs(t, err, ErrNotInProgress)
+}
+
+func TestRunRestack_NothingToRestack(t *testing.T) {
+	path := createTestRepo(t)
+
+	var buf bytes.Buffer
+	err := runRestack(&Options{Path: path, Output: &buf}, "")
+	require.NoError(t, err)
+	assert.Contains(t, buf.String(), "Nothing to restack below master")
+
+	err = runRestack(&Options{Path: path, Output: &buf}, "missing")
+	assert.ErrorIs(t, err, ErrBranchNotFound)
+}
+
+func TestNewRestackCommand(t *testing.T) {
+	cmd := NewRestackCommand()
+
+	assert.Equal(t, "restack [branch]", cmd.Use)
+	assert.NotEmpty(t, cmd.Short)
+	assert.NotEmpty(t, cmd.Long)
+	assert.NotNil(t, cmd.RunE)
+
+	for _, name := range []string{"path", "trunk", "continue", "abort"} {
+		assert.NotNil(t, cmd.Flags().Lookup(name), name)
+	}
+}
+
+func branchCommit(t *testing.T, repo *git.Repository, name string) *object.Commit {
+	t.Helper()
+
+	ref, err := repo.Reference(plumbing.NewBranchReferenceName(name), true)
+	require.NoError(t, err)
+	commit, err := repo.CommitObject(ref.Hash())
+	require.NoError(t, err)
+	return commit
+}
+
+// commits a second apart, so walks ordered by commit date are exact
+var commitClock = time.Now().Add(-time.Hour)
+
+func nextCommitTime() time.Time {
+	commitClock = commitClock.Add(time.Second)
+	return commitClock
+}
+
+func runGit(t *testing.T, dir string, args ...string) {
+	t.Helper()
+
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(out))
+}
+
+// restacking runs the git binary, which needs an identity to commit with
+func createTestRepo(t *testing.T) string {
+	t.Helper()
+
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git binary not available")
+	}
+	for _, key := range []string{"AUTHOR", "COMMITTER"} {
+		t.Setenv("GIT_"+key+"_NAME", "Test User")
+		t.Setenv("GIT_"+key+"_EMAIL", "test@example.com")
+	}
+
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	require.NoError(t, err)
+
+	w, err := repo.Worktree()
+	require.NoError(t, err)
+
+	filename := filepath.Join(dir, "README.md")
+	err = os.WriteFile(filename, []byte("# Test"), 0644)
+	require.NoError(t, err)
+
+	_, err = w.Add("README.md")
+	require.NoError(t, err)
+
+	_, err = w.Commit("Initial commit", &git.CommitOptions{
+		Author: &object.Signature{
+			Name:  "Test User",
+			Email: "test@example.com",
+			When:  nextCommitTime(),
+		},
+	})
+	require.NoError(t, err)
+
+	return dir
+}
+
+func openRepo(t *testing.T, path string) *git.Repository {
+	t.Helper()
+
+	repo, err := git.PlainOpen(path)
+	require.NoError(t, err)
+	return repo
+}
+
+// branches are created, checked out and committed to with the git binary,
+// so that they have the reflogs fork-point inference relies on
+func createBranch(t *testing.T, repo *git.Repository, name string) {
+	t.Helper()
+
+	runGit(t, worktreeRoot(t, repo), "branch", name)
+}
+
+func checkoutBranch(t *testing.T, repo *git.Repository, name string) {
+	t.Helper()
+
+	runGit(t, worktreeRoot(t, repo), "checkout", "-q", name)
+}
+
+func commitFile(t *testing.T, repo *git.Repository, filename, content string) {
+	t.Helper()
+
+	root := worktreeRoot(t, repo)
+	err := os.WriteFile(filepath.Join(root, filename), []byte(content), 0644)
+	require.NoError(t, err)
+
+	runGit(t, root, "add", filename)
+
+	runGitAt(t, root, "commit", "-q", "-m", "Add "+filename)
+}
+
+// runs git at the next commit time, for commands that write commits
+func runGitAt(t *testing.T, dir string, args ...string) {
+	t.Helper()
+
+	when := nextCommitTime().Format(time.RFC3339)
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GIT_AUTHOR_DATE="+when, "GIT_COMMITTER_DATE="+when)
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(out))
+}
+
+func worktreeRoot(t *testing.T, repo *git.Repository) string {
+	t.Helper()
+
+	w, err := repo.Worktree()
+	require.NoError(t, err)
+	return w.Filesystem.Root()
+}
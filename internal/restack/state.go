@@ -0,0 +1,73 @@
+package restack
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// name of the file in the .git directory that records an interrupted restack
+const stateFile = "gittree-restack.json"
+
+// a branch to rebase onto the new tip of its parent
+type step struct {
+	Branch string `json:"branch"`
+	Parent string `json:"parent"`
+	// set for branches sharing a tip with another one, which are moved to
+	// that branch's new tip instead of being rebased on their own
+	Follow string `json:"follow,omitempty"`
+}
+
+// progress of a restack, saved so it can be continued or aborted after a
+// conflict. The first step is the one being rebased when the restack stopped.
+type state struct {
+	// branch checked out when the restack started
+	Original string `json:"original"`
+	Steps    []step `json:"steps"`
+	// tips of the branches rebased so far, from before they were rebased
+	OldTips map[string]string `json:"oldTips"`
+}
+
+func statePath(gitDir string) string {
+	return filepath.Join(gitDir, stateFile)
+}
+
+// returns the saved state, or nil when no restack is in progress
+func loadState(gitDir string) (*state, error) {
+	data, err := os.ReadFile(statePath(gitDir))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading restack state: %w", err)
+	}
+
+	var s state
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("parsing restack state: %w", err)
+	}
+	if s.OldTips == nil {
+		s.OldTips = make(map[string]string)
+	}
+	return &s, nil
+}
+
+func saveState(gitDir string, s *state) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding restack state: %w", err)
+	}
+	if err := os.WriteFile(statePath(gitDir), data, 0644); err != nil {
+		return fmt.Errorf("writing restack state: %w", err)
+	}
+	return nil
+}
+
+func removeState(gitDir string) error {
+	if err := os.Remove(statePath(gitDir)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("removing restack state: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package setparent
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/mucansever/gittree/internal/git"
+)
+
+const (
+	defaultPath = "."
+)
+
+var (
+	ErrOwnParent      = errors.New("a branch cannot be its own parent")
+	ErrUnknownBranch  = errors.New("branch not found")
+	ErrMissingParent  = errors.New("parent branch required")
+	ErrUnexpectedArgs = errors.New("--unset takes only the branch")
+)
+
+type Options struct {
+	Path   string
+	Unset  bool
+	Output io.Writer
+}
+
+func NewSetParentCommand() *cobra.Command {
+	opts := &Options{
+		Output: os.Stdout,
+	}
+
+	cmd := &cobra.Command{
+		Use:   "set-parent <branch> [parent]",
+		Short: "Record the parent of a branch",
+		Long: `Record parent as the parent of branch, overriding the inferred one.
+Inference cannot tell apart branches that point at the same commit, and
+loses track of a branch once its parent is rebased; a recorded parent
+holds in both cases. It is stored in the repository config as
+branch.<branch>.gittreeParent, and drawn with a double line in the tree.
+
+Use --unset to go back to the inferred parent.`,
+		Args: cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runSetParent(opts, args)
+		},
+	}
+
+	cmd.Flags().StringVarP(&opts.Path, "path", "p", defaultPath,
+		"Path to the git repository")
+	cmd.Flags().BoolVar(&opts.Unset, "unset", false,
+		"Remove the recorded parent")
+
+	return cmd
+}
+
+func runSetParent(opts *Options, args []string) error {
+	repo, err := git.Open(opts.Path)
+	if err != nil {
+		return fmt.Errorf("failed to open repository: %w", err)
+	}
+
+	branch := args[0]
+	if _, err := repo.GetBranch(branch); err != nil {
+		return fmt.Errorf("%w: %s", ErrUnknownBranch, branch)
+	}
+
+	if opts.Unset {
+		if len(args) > 1 {
+			return ErrUnexpectedArgs
+		}
+		if err := repo.UnsetBranchParent(branch); err != nil {
+			return fmt.Errorf("failed to unset parent: %w", err)
+		}
+		fmt.Fprintf(opts.Output, "Removed the recorded parent of %s\n", branch)
+		return nil
+	}
+
+	if len(args) < 2 {
+		return ErrMissingParent
+	}
+	parent := args[1]
+	if parent == branch {
+		return ErrOwnParent
+	}
+
+	exists, err := branchExists(repo, parent)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("%w: %s", ErrUnknownBranch, parent)
+	}
+
+	if err := repo.SetBranchParent(branch, parent); err != nil {
+		return fmt.Errorf("failed to set parent: %w", err)
+	}
+	fmt.Fprintf(opts.Output, "Recorded %s as the parent of %s\n", parent, branch)
+	return nil
+}
+
+// reports whether name is a local or remote-tracking branch
+func branchExists(repo *git.Repository, name string) (bool, error) {
+	if _, err := repo.GetBranch(name); err == nil {
+		return true, nil
+	}
+
+	remote, err := repo.GetRemoteBranches()
+	if err != nil {
+		return false, fmt.Errorf("failed to get remote branches: %w", err)
+	}
+	for _, b := range remote {
+		if b.Name == name {
+			return true, nil
+		}
+	}
+	return false, nil
+}
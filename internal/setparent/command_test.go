@@ -0,0 +1,159 @@
+package setparent
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	gittree "github.com/mucansever/gittree/internal/git"
+)
+
+func TestRunSetParent(t *testing.T) {
+	path := createTestRepo(t)
+	repo := openRepo(t, path)
+	createBranch(t, repo, "feat/x")
+	createBranch(t, repo, "feat/y")
+
+	tests := []struct {
+		name    string
+		args    []string
+		unset   bool
+		wantErr error
+		want    map[string]string
+	}{
+		{
+			name: "record parent",
+			args: []string{"feat/y", "feat/x"},
+			want: map[string]string{"feat/y": "feat/x"},
+		},
+		{
+			name: "replace parent",
+			args: []string{"feat/y", "master"},
+			want: map[string]string{"feat/y": "master"},
+		},
+		{
+			name:    "own parent",
+			args:    []string{"feat/y", "feat/y"},
+			wantErr: ErrOwnParent,
+			want:    map[string]string{"feat/y": "master"},
+		},
+		{
+			name:    "unknown parent",
+			args:    []string{"feat/y", "gone"},
+			wantErr: ErrUnknownBranch,
+			want:    map[string]string{"feat/y": "master"},
+		},
+		{
+			name:    "unknown branch",
+			args:    []string{"gone", "master"},
+			wantErr: ErrUnknownBranch,
+			want:    map[string]string{"feat/y": "master"},
+		},
+		{
+			name:    "missing parent",
+			args:    []string{"feat/y"},
+			wantErr: ErrMissingParent,
+			want:    map[string]string{"feat/y": "master"},
+		},
+		{
+			name:    "unset with parent",
+			args:    []string{"feat/y", "master"},
+			unset:   true,
+			wantErr: ErrUnexpectedArgs,
+			want:    map[string]string{"feat/y": "master"},
+		},
+		{
+			name:  "unset",
+			args:  []string{"feat/y"},
+			unset: true,
+			want:  map[string]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := runSetParent(&Options{Path: path, Unset: tt.unset, Output: &buf}, tt.args)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+
+			r, err := gittree.Open(path)
+			require.NoError(t, err)
+			parents, err := r.GetBranchParents()
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, parents)
+		})
+	}
+}
+
+func TestNewSetParentCommand(t *testing.T) {
+	cmd := NewSetParentCommand()
+
+	assert.Equal(t, "set-parent <branch> [parent]", cmd.Use)
+	assert.NotEmpty(t, cmd.Short)
+	assert.NotEmpty(t, cmd.Long)
+	assert.NotNil(t, cmd.RunE)
+
+	for _, name := range []string{"path", "unset"} {
+		assert.NotNil(t, cmd.Flags().Lookup(name), name)
+	}
+}
+
+func createTestRepo(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	require.NoError(t, err)
+
+	w, err := repo.Worktree()
+	require.NoError(t, err)
+
+	filename := filepath.Join(dir, "README.md")
+	err = os.WriteFile(filename, []byte("# Test"), 0644)
+	require.NoError(t, err)
+
+	_, err = w.Add("README.md")
+	require.NoError(t, err)
+
+	_, err = w.Commit("Initial commit", &git.CommitOptions{
+		Author: &object.Signature{
+			Name:  "Test User",
+			Email: "test@example.com",
+			When:  time.Now(),
+		},
+	})
+	require.NoError(t, err)
+
+	return dir
+}
+
+func openRepo(t *testing.T, path string) *git.Repository {
+	t.Helper()
+
+	repo, err := git.PlainOpen(path)
+	require.NoError(t, err)
+	return repo
+}
+
+func createBranch(t *testing.T, repo *git.Repository, name string) {
+	t.Helper()
+
+	head, err := repo.Head()
+	require.NoError(t, err)
+
+	ref := plumbing.NewHashReference(plumbing.NewBranchReferenceName(name), head.Hash())
+	err = repo.Storer.SetReference(ref)
+	require.NoError(t, err)
+}
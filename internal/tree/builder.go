@@ -2,6 +2,7 @@ package tree
 
 import (
 	"errors"
+	"sort"
 	"time"
 )
 
@@ -14,6 +15,41 @@ const rootNodeName = "."
 type Builder struct {
 	relationships map[string]map[string]bool
 	meta          map[string]time.Time
+	forkPoints    map[string][]ForkPoint
+	trunk         string
+	sources       map[string]string
+	recorded      map[string]string
+	tips          map[string]string
+	commits       map[string]Commit
+	dag           bool
+	// the branch standing in for a detached HEAD, and its commit
+	detached    string
+	detachedTip string
+	// representative branch -> other branches sharing its tip
+	aliases map[string][]string
+}
+
+// Commit describes the tip commit of a branch.
+type Commit struct {
+	Hash        string
+	Subject     string
+	AuthorName  string
+	AuthorEmail string
+}
+
+// ForkPoint describes the most recent commit a branch shares with a
+// possible parent.
+type ForkPoint struct {
+	Parent string
+	// position of the shared commit in history, lower is more recent
+	Rank int
+	// the shared commit is the parent's tip, so the parent is an ancestor
+	AtParentTip bool
+	// the shared commit is the branch's own tip, so the parent contains it
+	AtBranchTip bool
+	// the branch's reflog shows it moving to the shared commit while the
+	// parent's does not, so the parent forked off the branch there
+	BranchMovedThere bool
 }
 
 func NewBuilder(relationships map[string]map[string]bool, meta map[string]time.Time) *Builder {
@@ -23,30 +59,98 @@ func NewBuilder(relationships map[string]map[string]bool, meta map[string]time.T
 	}
 }
 
-func (b *Builder) Build(currentBranch string) (*Tree, error) {
-	working := b.copyRelationships()
+// SetForkPoints switches the builder from strict ancestry to fork-point
+// inference: every branch is placed under the branch it shares the most
+// recent merge-base with, even if that branch has moved on since. The trunk
+// is always kept at the top level and may adopt branches it already contains.
+func (b *Builder) SetForkPoints(forkPoints map[string][]ForkPoint, trunk string) {
+	b.forkPoints = forkPoints
+	b.trunk = trunk
+}
 
-	b.pruneRelationships(working)
+// SetTrunk names the trunk branch, which represents any other branches
+// pointing at the same commit.
+func (b *Builder) SetTrunk(trunk string) {
+	b.trunk = trunk
+}
 
-	if currentBranch != "" {
-		markedBranch := currentBranch + "*"
-		if children, exists := working[currentBranch]; exists {
-			working[markedBranch] = children
-			delete(working, currentBranch)
+// SetTips gives the tip commit of every branch. Branches sharing a tip are
+// collapsed into a single node, named after the trunk if it is among them
+// and otherwise after the first in alphabetical order, with the others
+// listed as its aliases. A branch whose recorded parent shares its tip is
+// kept apart, so the two can be stacked.
+func (b *Builder) SetTips(tips map[string]string) {
+	b.tips = tips
+}
 
-			if t, ok := b.meta[currentBranch]; ok {
-				b.meta[markedBranch] = t
-			}
+// SetDetachedHead names the branch standing in for a detached HEAD at tip.
+// When HEAD sits at the tip of another branch it goes below that branch, so
+// the branches built on that commit are listed once.
+func (b *Builder) SetDetachedHead(name, tip string) {
+	b.detached = name
+	b.detachedTip = tip
+}
 
-			for branch := range working {
-				if working[branch][currentBranch] {
-					delete(working[branch], currentBranch)
-					working[branch][markedBranch] = true
-				}
+// SetCommits gives the tip commit of every branch, which is copied into its
+// node.
+func (b *Builder) SetCommits(commits map[string]Commit) {
+	b.commits = commits
+}
+
+// SetDAG makes every branch appear once even when it descends from several
+// branches that are unrelated to each other. The branch stays under the
+// parent with the most recent commit and lists the others in AlsoFrom.
+func (b *Builder) SetDAG(enabled bool) {
+	b.dag = enabled
+}
+
+// SetCreationSources switches the builder to placing every branch under the
+// branch it was created from, keyed by branch. Branches without a known
+// source, or whose source is gone, keep the parent inferred from ancestry.
+func (b *Builder) SetCreationSources(sources map[string]string) {
+	b.sources = sources
+}
+
+// SetRecordedParents sets parents the user recorded explicitly, keyed by
+// branch. They take precedence over inferred ones, except where the parent
+// is unknown or would make a branch its own ancestor.
+func (b *Builder) SetRecordedParents(parents map[string]string) {
+	b.recorded = parents
+}
+
+func (b *Builder) Build(currentBranch string) (*Tree, error) {
+	if b.aliases == nil {
+		b.aliases = b.collapseAliases()
+	}
+	aliases := make(map[string][]string, len(b.aliases))
+	// representative branch -> its alias that is checked out
+	currentAlias := make(map[string]string)
+	for branch, names := range b.aliases {
+		aliases[branch] = append([]string(nil), names...)
+		for _, name := range names {
+			if name == currentBranch {
+				currentAlias[branch] = name
 			}
 		}
 	}
 
+	var working map[string]map[string]bool
+	if b.forkPoints != nil {
+		working = b.forkPointRelationships()
+		b.nestDetachedHead(working)
+	} else {
+		working = b.copyRelationships()
+		b.nestDetachedHead(working)
+		b.pruneRelationships(working)
+	}
+	b.applyParents(working, b.sources)
+	recorded := b.applyParents(working, b.recorded)
+
+	alsoFrom := make(map[string][]string)
+	if b.dag {
+		alsoFrom = b.keepSingleParent(working)
+	}
+
 	topLevelBranches := make(map[string]bool)
 	referencedBranches := make(map[string]bool)
 	for _, children := range working {
@@ -65,13 +169,323 @@ func (b *Builder) Build(currentBranch string) (*Tree, error) {
 	nodes := make(map[string]*Node)
 	for branch := range working {
 		nodes[branch] = NewNode(branch, b.meta[branch])
+		nodes[branch].Recorded = recorded[branch]
+		nodes[branch].Aliases = aliases[branch]
+		nodes[branch].AlsoFrom = alsoFrom[branch]
+		nodes[branch].IsCurrent = branch == currentBranch || currentAlias[branch] != ""
+		nodes[branch].CurrentAlias = currentAlias[branch]
+		if c, ok := b.commits[branch]; ok {
+			nodes[branch].Hash = c.Hash
+			nodes[branch].Subject = c.Subject
+			nodes[branch].Author = c.AuthorName
+			nodes[branch].AuthorEmail = c.AuthorEmail
+		}
 	}
 
 	if err := b.buildHierarchy(working, nodes); err != nil {
 		return nil, err
 	}
 
-	return &Tree{Root: nodes[rootNodeName]}, nil
+	t := &Tree{Root: nodes[rootNodeName]}
+	// children come out of maps, so give them a stable order
+	sortTree(t, byName)
+	return t, nil
+}
+
+// Groups branches by tip and rewrites the builder's inputs so that only one
+// branch of each group remains, standing in for the others. Returns the
+// aliases of every remaining branch that has any.
+func (b *Builder) collapseAliases() map[string][]string {
+	aliases := make(map[string][]string)
+	if len(b.tips) == 0 {
+		return aliases
+	}
+
+	names := make([]string, 0, len(b.relationships))
+	for name := range b.relationships {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	groups := make(map[string][]string)
+	for _, name := range names {
+		tip, ok := b.tips[name]
+		if !ok {
+			continue
+		}
+		// a parent recorded at the same commit asks for a stack, not a group
+		if parent, ok := b.recorded[name]; ok && b.tips[parent] == tip {
+			continue
+		}
+		groups[tip] = append(groups[tip], name)
+	}
+
+	canonical := make(map[string]string)
+	for _, group := range groups {
+		if len(group) < 2 {
+			continue
+		}
+		rep := group[0]
+		for _, name := range group {
+			if name == b.trunk {
+				rep = name
+			}
+		}
+		for _, name := range group {
+			canonical[name] = rep
+			if name != rep {
+				aliases[rep] = append(aliases[rep], name)
+			}
+		}
+	}
+	if len(canonical) == 0 {
+		return aliases
+	}
+
+	canon := func(name string) string {
+		if rep, ok := canonical[name]; ok {
+			return rep
+		}
+		return name
+	}
+
+	relationships := make(map[string]map[string]bool)
+	for _, name := range names {
+		rep := canon(name)
+		if relationships[rep] == nil {
+			relationships[rep] = make(map[string]bool)
+		}
+		for child := range b.relationships[name] {
+			if c := canon(child); c != rep {
+				relationships[rep][c] = true
+			}
+		}
+	}
+	b.relationships = relationships
+
+	if b.forkPoints != nil {
+		forkPoints := make(map[string][]ForkPoint)
+		for branch := range relationships {
+			seen := make(map[string]bool)
+			for _, fp := range b.forkPoints[branch] {
+				fp.Parent = canon(fp.Parent)
+				if fp.Parent == branch || seen[fp.Parent] {
+					continue
+				}
+				seen[fp.Parent] = true
+				forkPoints[branch] = append(forkPoints[branch], fp)
+			}
+		}
+		b.forkPoints = forkPoints
+	}
+
+	b.sources = canonicalParents(b.sources, names, canon)
+	b.recorded = canonicalParents(b.recorded, names, canon)
+
+	return aliases
+}
+
+// renames the branches in a child -> parent map to their representatives;
+// a group takes the parent of its first member that has one
+func canonicalParents(parents map[string]string, names []string, canon func(string) string) map[string]string {
+	if parents == nil {
+		return nil
+	}
+
+	result := make(map[string]string)
+	for _, name := range names {
+		parent, ok := parents[name]
+		if !ok {
+			continue
+		}
+		child := canon(name)
+		if _, done := result[child]; done || canon(parent) == child {
+			continue
+		}
+		result[child] = canon(parent)
+	}
+	return result
+}
+
+// picks a single parent for every branch from its fork points and returns
+// the resulting parent -> children links
+func (b *Builder) forkPointRelationships() map[string]map[string]bool {
+	branches := make([]string, 0, len(b.relationships))
+	for branch := range b.relationships {
+		branches = append(branches, branch)
+	}
+	sort.Strings(branches)
+
+	parents := make(map[string]string)
+	for _, branch := range branches {
+		if branch == b.trunk {
+			continue
+		}
+
+		var candidates []ForkPoint
+		for _, fp := range b.forkPoints[branch] {
+			// branches containing this one are descendants, not parents, and
+			// so are branches forked off a commit this one moved to itself
+			if (fp.AtBranchTip || fp.BranchMovedThere) && fp.Parent != b.trunk {
+				continue
+			}
+			if _, ok := b.relationships[fp.Parent]; !ok {
+				continue
+			}
+			candidates = append(candidates, fp)
+		}
+		sort.SliceStable(candidates, func(i, j int) bool {
+			return b.betterForkPoint(candidates[i], candidates[j])
+		})
+
+		for _, fp := range candidates {
+			if !createsCycle(parents, branch, fp.Parent) {
+				parents[branch] = fp.Parent
+				break
+			}
+		}
+	}
+
+	rels := make(map[string]map[string]bool, len(branches))
+	for _, branch := range branches {
+		rels[branch] = make(map[string]bool)
+	}
+	for child, parent := range parents {
+		rels[parent][child] = true
+	}
+	return rels
+}
+
+// moves every branch with a usable parent in parents under that parent and
+// returns the set of branches placed this way
+func (b *Builder) applyParents(rels map[string]map[string]bool, parents map[string]string) map[string]bool {
+	placed := make(map[string]bool)
+
+	children := make([]string, 0, len(parents))
+	for child := range parents {
+		children = append(children, child)
+	}
+	sort.Strings(children)
+
+	for _, child := range children {
+		parent := parents[child]
+		if _, ok := rels[child]; !ok {
+			continue
+		}
+		if _, ok := rels[parent]; !ok || parent == child {
+			continue
+		}
+		if b.isDescendant(rels, child, parent) {
+			continue
+		}
+
+		for _, siblings := range rels {
+			delete(siblings, child)
+		}
+		rels[parent][child] = true
+		placed[child] = true
+	}
+
+	return placed
+}
+
+// moves the detached HEAD below the branch at its commit, handing its
+// children and, if that branch hung below HEAD, its parents to the branch
+func (b *Builder) nestDetachedHead(rels map[string]map[string]bool) {
+	if _, ok := rels[b.detached]; !ok {
+		return
+	}
+	branch := ""
+	for name := range rels {
+		if name == b.detached || b.tips[name] != b.detachedTip {
+			continue
+		}
+		if branch == "" || name == b.trunk || (branch != b.trunk && name < branch) {
+			branch = name
+		}
+	}
+	if branch == "" {
+		return
+	}
+
+	var parents []string
+	for parent, children := range rels {
+		if children[b.detached] {
+			parents = append(parents, parent)
+			delete(children, b.detached)
+		}
+	}
+	for child := range rels[b.detached] {
+		if child == branch {
+			for _, parent := range parents {
+				if parent != branch {
+					rels[parent][branch] = true
+				}
+			}
+			continue
+		}
+		rels[branch][child] = true
+	}
+	rels[b.detached] = make(map[string]bool)
+	rels[branch][b.detached] = true
+}
+
+// Leaves every branch with a single parent, the one with the most recent
+// commit, and returns the parents dropped for each branch.
+func (b *Builder) keepSingleParent(rels map[string]map[string]bool) map[string][]string {
+	parents := make(map[string][]string)
+	for parent, children := range rels {
+		for child := range children {
+			parents[child] = append(parents[child], parent)
+		}
+	}
+
+	dropped := make(map[string][]string)
+	for child, candidates := range parents {
+		if len(candidates) < 2 {
+			continue
+		}
+		sort.Slice(candidates, func(i, j int) bool {
+			ti, tj := b.meta[candidates[i]], b.meta[candidates[j]]
+			if !ti.Equal(tj) {
+				return ti.After(tj)
+			}
+			return candidates[i] < candidates[j]
+		})
+
+		for _, parent := range candidates[1:] {
+			delete(rels[parent], child)
+		}
+		others := append([]string(nil), candidates[1:]...)
+		sort.Strings(others)
+		dropped[child] = others
+	}
+
+	return dropped
+}
+
+// orders fork points by recency, then prefers ancestors, then the trunk
+func (b *Builder) betterForkPoint(x, y ForkPoint) bool {
+	if x.Rank != y.Rank {
+		return x.Rank < y.Rank
+	}
+	if x.AtParentTip != y.AtParentTip {
+		return x.AtParentTip
+	}
+	if (x.Parent == b.trunk) != (y.Parent == b.trunk) {
+		return x.Parent == b.trunk
+	}
+	return x.Parent < y.Parent
+}
+
+// checks if making parent the parent of child would close a loop
+func createsCycle(parents map[string]string, child, parent string) bool {
+	for current, ok := parent, true; ok; current, ok = parents[current] {
+		if current == child {
+			return true
+		}
+	}
+	return false
 }
 
 // removes redundant direct links to descendants
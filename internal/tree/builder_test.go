@@ -2,6 +2,7 @@ package tree
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -28,9 +29,11 @@ func TestBuilder_Build(t *testing.T) {
 				assert.Len(t, tree.Root.Children, 1)
 
 				master := tree.Root.Children[0]
-				assert.Equal(t, "master*", master.Name)
+				assert.Equal(t, "master", master.Name)
+				assert.True(t, master.IsCurrent)
 				assert.Len(t, master.Children, 1)
 				assert.Equal(t, "feature", master.Children[0].Name)
+				assert.False(t, master.Children[0].IsCurrent)
 			},
 			wantErr: nil,
 		},
@@ -49,8 +52,9 @@ func TestBuilder_Build(t *testing.T) {
 
 				// Check both features exist
 				childNames := []string{master.Children[0].Name, master.Children[1].Name}
-				assert.Contains(t, childNames, "feature1*")
+				assert.Contains(t, childNames, "feature1")
 				assert.Contains(t, childNames, "feature2")
+				assert.True(t, master.Children[0].IsCurrent)
 			},
 			wantErr: nil,
 		},
@@ -70,7 +74,8 @@ func TestBuilder_Build(t *testing.T) {
 				assert.Len(t, master.Children, 1)
 
 				develop := master.Children[0]
-				assert.Equal(t, "develop*", develop.Name)
+				assert.Equal(t, "develop", develop.Name)
+				assert.True(t, develop.IsCurrent)
 				assert.Len(t, develop.Children, 2)
 			},
 			wantErr: nil,
@@ -85,6 +90,7 @@ func TestBuilder_Build(t *testing.T) {
 			check: func(t *testing.T, tree *Tree) {
 				master := tree.Root.Children[0]
 				assert.Equal(t, "master", master.Name)
+				assert.False(t, master.IsCurrent)
 			},
 			wantErr: nil,
 		},
@@ -96,7 +102,8 @@ func TestBuilder_Build(t *testing.T) {
 			currentBranch: "master",
 			check: func(t *testing.T, tree *Tree) {
 				assert.Len(t, tree.Root.Children, 1)
-				assert.Equal(t, "master*", tree.Root.Children[0].Name)
+				assert.Equal(t, "master", tree.Root.Children[0].Name)
+				assert.True(t, tree.Root.Children[0].IsCurrent)
 			},
 			wantErr: nil,
 		},
@@ -136,7 +143,8 @@ func TestBuilder_Build_Transitive(t *testing.T) {
 			check: func(t *testing.T, tree *Tree) {
 				// main should only have one direct child: feat/feature-1
 				main := tree.Root.Children[0]
-				assert.Equal(t, "main*", main.Name)
+				assert.Equal(t, "main", main.Name)
+				assert.True(t, main.IsCurrent)
 				require.Len(t, main.Children, 1)
 
 				feat := main.Children[0]
@@ -159,6 +167,422 @@ func TestBuilder_Build_Transitive(t *testing.T) {
 	}
 }
 
+func TestBuilder_Build_ForkPoints(t *testing.T) {
+	tests := []struct {
+		name       string
+		branches   []string
+		forkPoints map[string][]ForkPoint
+		trunk      string
+		want       map[string][]string
+	}{
+		{
+			name:     "diverged branch goes under the trunk",
+			branches: []string{"main", "feature"},
+			forkPoints: map[string][]ForkPoint{
+				"main":    {{Parent: "feature", Rank: 0}},
+				"feature": {{Parent: "main", Rank: 0}},
+			},
+			trunk: "main",
+			want: map[string][]string{
+				".":    {"main"},
+				"main": {"feature"},
+			},
+		},
+		{
+			name:     "most recent fork point wins",
+			branches: []string{"main", "feat-a", "feat-b"},
+			forkPoints: map[string][]ForkPoint{
+				"main":   {{Parent: "feat-a", Rank: 1}, {Parent: "feat-b", Rank: 1}},
+				"feat-a": {{Parent: "feat-b", Rank: 0}, {Parent: "main", Rank: 1}},
+				"feat-b": {{Parent: "feat-a", Rank: 0}, {Parent: "main", Rank: 1}},
+			},
+			trunk: "main",
+			want: map[string][]string{
+				".":      {"main"},
+				"main":   {"feat-b"},
+				"feat-b": {"feat-a"},
+			},
+		},
+		{
+			name:     "parent that moved on is told apart by its reflog",
+			branches: []string{"main", "feat-a", "feat-b"},
+			forkPoints: map[string][]ForkPoint{
+				"main":   {{Parent: "feat-a", Rank: 1}, {Parent: "feat-b", Rank: 1}},
+				"feat-a": {{Parent: "feat-b", Rank: 0, BranchMovedThere: true}, {Parent: "main", Rank: 1}},
+				"feat-b": {{Parent: "feat-a", Rank: 0}, {Parent: "main", Rank: 1}},
+			},
+			trunk: "main",
+			want: map[string][]string{
+				".":      {"main"},
+				"main":   {"feat-a"},
+				"feat-a": {"feat-b"},
+			},
+		},
+		{
+			name:     "ancestor preferred over diverged sibling at the same commit",
+			branches: []string{"main", "base", "feature"},
+			forkPoints: map[string][]ForkPoint{
+				"feature": {{Parent: "main", Rank: 0}, {Parent: "base", Rank: 0, AtParentTip: true}},
+				"base":    {{Parent: "main", Rank: 0}, {Parent: "feature", Rank: 0, AtBranchTip: true}},
+			},
+			trunk: "main",
+			want: map[string][]string{
+				".":    {"main"},
+				"main": {"base"},
+				"base": {"feature"},
+			},
+		},
+		{
+			name:     "trunk adopts branches it contains",
+			branches: []string{"main", "merged"},
+			forkPoints: map[string][]ForkPoint{
+				"main":   {{Parent: "merged", Rank: 0, AtParentTip: true}},
+				"merged": {{Parent: "main", Rank: 0, AtBranchTip: true}},
+			},
+			trunk: "main",
+			want: map[string][]string{
+				".":    {"main"},
+				"main": {"merged"},
+			},
+		},
+		{
+			name:     "unrelated branches stay at the top level",
+			branches: []string{"main", "orphan"},
+			trunk:    "main",
+			want: map[string][]string{
+				".": {"main", "orphan"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			relationships := make(map[string]map[string]bool)
+			for _, branch := range tt.branches {
+				relationships[branch] = map[string]bool{}
+			}
+
+			builder := NewBuilder(relationships, nil)
+			builder.SetForkPoints(tt.forkPoints, tt.trunk)
+			tree, err := builder.Build("")
+			require.NoError(t, err)
+
+			got := make(map[string][]string)
+			var collect func(node *Node)
+			collect = func(node *Node) {
+				for _, child := range node.Children {
+					got[node.Name] = append(got[node.Name], child.Name)
+					collect(child)
+				}
+			}
+			collect(tree.Root)
+
+			require.Len(t, got, len(tt.want))
+			for parent, children := range tt.want {
+				assert.ElementsMatch(t, children, got[parent], parent)
+			}
+		})
+	}
+}
+
+func TestBuilder_Build_RecordedParents(t *testing.T) {
+	tests := []struct {
+		name          string
+		relationships map[string]map[string]bool
+		recorded      map[string]string
+		current       string
+		want          map[string][]string
+		wantRecorded  []string
+	}{
+		{
+			name: "recorded parent overrides ancestry",
+			relationships: map[string]map[string]bool{
+				"main":   {"feat-a": true, "feat-b": true},
+				"feat-a": {"feat-b": true},
+				"feat-b": {},
+			},
+			recorded: map[string]string{"feat-b": "main"},
+			want: map[string][]string{
+				".":    {"main"},
+				"main": {"feat-a", "feat-b"},
+			},
+			wantRecorded: []string{"feat-b"},
+		},
+		{
+			name: "branches at the same commit",
+			relationships: map[string]map[string]bool{
+				"main":   {"feat-a": true, "feat-b": true},
+				"feat-a": {"feat-b": true},
+				"feat-b": {"feat-a": true},
+			},
+			recorded: map[string]string{"feat-a": "main", "feat-b": "feat-a"},
+			current:  "feat-a",
+			want: map[string][]string{
+				".":      {"main"},
+				"main":   {"feat-a"},
+				"feat-a": {"feat-b"},
+			},
+			wantRecorded: []string{"feat-a", "feat-b"},
+		},
+		{
+			name: "unknown parent and cycles are ignored",
+			relationships: map[string]map[string]bool{
+				"main":   {"feat-a": true},
+				"feat-a": {},
+			},
+			recorded: map[string]string{"main": "feat-a", "feat-a": "gone"},
+			want: map[string][]string{
+				".":    {"main"},
+				"main": {"feat-a"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			builder := NewBuilder(tt.relationships, nil)
+			builder.SetRecordedParents(tt.recorded)
+			tree, err := builder.Build(tt.current)
+			require.NoError(t, err)
+
+			got := make(map[string][]string)
+			var gotRecorded []string
+			tree.Walk(func(node *Node) {
+				for _, child := range node.Children {
+					got[node.Name] = append(got[node.Name], child.Name)
+				}
+				if node.Recorded {
+					gotRecorded = append(gotRecorded, node.Name)
+				}
+			})
+
+			require.Len(t, got, len(tt.want))
+			for parent, children := range tt.want {
+				assert.ElementsMatch(t, children, got[parent], parent)
+			}
+			assert.ElementsMatch(t, tt.wantRecorded, gotRecorded)
+		})
+	}
+}
+
+func TestBuilder_Build_CreationSources(t *testing.T) {
+	// develop has moved on since feat-a was created from it
+	relationships := map[string]map[string]bool{
+		"main":    {"develop": true, "feat-a": true, "feat-b": true},
+		"develop": {},
+		"feat-a":  {"feat-b": true},
+		"feat-b":  {},
+	}
+
+	builder := NewBuilder(relationships, nil)
+	// feat-b's source is gone, so it falls back to ancestry
+	builder.SetCreationSources(map[string]string{"feat-a": "develop", "feat-b": "deleted"})
+	builder.SetRecordedParents(map[string]string{"develop": "main"})
+	tree, err := builder.Build("")
+	require.NoError(t, err)
+
+	require.Len(t, tree.Root.Children, 1)
+	main := tree.Root.Children[0]
+	require.Len(t, main.Children, 1)
+	develop := main.Children[0]
+	assert.Equal(t, "develop", develop.Name)
+	assert.True(t, develop.Recorded)
+
+	require.Len(t, develop.Children, 1)
+	featA := develop.Children[0]
+	assert.Equal(t, "feat-a", featA.Name)
+	assert.False(t, featA.Recorded)
+
+	require.Len(t, featA.Children, 1)
+	assert.Equal(t, "feat-b", featA.Children[0].Name)
+}
+
+func TestBuilder_Build_Aliases(t *testing.T) {
+	// main and no-commit share a tip, so both contain every other branch
+	relationships := map[string]map[string]bool{
+		"main":      {"feature": true, "child": true},
+		"no-commit": {"feature": true, "child": true},
+		"feature":   {"child": true},
+		"child":     {},
+		"other":     {},
+	}
+	tips := map[string]string{
+		"main":      "aaa",
+		"no-commit": "aaa",
+		"feature":   "bbb",
+		"child":     "ccc",
+		"other":     "ccc",
+	}
+
+	builder := NewBuilder(relationships, nil)
+	builder.SetTrunk("main")
+	builder.SetTips(tips)
+	tree, err := builder.Build("no-commit")
+	require.NoError(t, err)
+
+	require.Len(t, tree.Root.Children, 1)
+	main := tree.Root.Children[0]
+	assert.Equal(t, "main", main.Name)
+	assert.Equal(t, []string{"no-commit"}, main.Aliases)
+	assert.True(t, main.IsCurrent)
+	assert.Equal(t, "no-commit", main.CurrentAlias)
+	assert.Equal(t, "no-commit", main.CheckedOut())
+
+	require.Len(t, main.Children, 1)
+	feature := main.Children[0]
+	assert.Equal(t, "feature", feature.Name)
+	assert.Empty(t, feature.Aliases)
+
+	require.Len(t, feature.Children, 1)
+	child := feature.Children[0]
+	assert.Equal(t, "child", child.Name)
+	assert.Equal(t, []string{"other"}, child.Aliases)
+}
+
+func TestBuilder_Build_DetachedHeadAtBranchTip(t *testing.T) {
+	// HEAD is detached at the tip of feature, so both contain child
+	relationships := map[string]map[string]bool{
+		"main":    {"feature": true, "HEAD": true, "child": true},
+		"feature": {"HEAD": true, "child": true},
+		"HEAD":    {"feature": true, "child": true},
+		"child":   {},
+	}
+	tips := map[string]string{
+		"main":    "aaa",
+		"feature": "bbb",
+		"child":   "ccc",
+	}
+
+	builder := NewBuilder(relationships, nil)
+	builder.SetTrunk("main")
+	builder.SetTips(tips)
+	builder.SetDetachedHead("HEAD", "bbb")
+	tree, err := builder.Build("HEAD")
+	require.NoError(t, err)
+
+	require.Len(t, tree.Root.Children, 1)
+	main := tree.Root.Children[0]
+	require.Len(t, main.Children, 1)
+	feature := main.Children[0]
+	assert.Equal(t, "feature", feature.Name)
+
+	require.Len(t, feature.Children, 2)
+	assert.Equal(t, "HEAD", feature.Children[0].Name)
+	assert.True(t, feature.Children[0].IsCurrent)
+	assert.Empty(t, feature.Children[0].Children)
+	assert.Equal(t, "child", feature.Children[1].Name)
+}
+
+func TestBuilder_Build_DAG(t *testing.T) {
+	// merge contains both feat-a and feat-b, which are unrelated
+	relationships := map[string]map[string]bool{
+		"main":   {"feat-a": true, "feat-b": true, "merge": true},
+		"feat-a": {"merge": true},
+		"feat-b": {"merge": true},
+		"merge":  {},
+	}
+	now := time.Now()
+	meta := map[string]time.Time{
+		"main":   now.Add(-3 * time.Hour),
+		"feat-a": now.Add(-2 * time.Hour),
+		"feat-b": now.Add(-1 * time.Hour),
+		"merge":  now,
+	}
+
+	tests := []struct {
+		name         string
+		dag          bool
+		wantMergeIn  []string
+		wantAlsoFrom []string
+	}{
+		{
+			name:        "tree repeats the branch under every parent",
+			wantMergeIn: []string{"feat-a", "feat-b"},
+		},
+		{
+			name:         "dag keeps the most recent parent",
+			dag:          true,
+			wantMergeIn:  []string{"feat-b"},
+			wantAlsoFrom: []string{"feat-a"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			builder := NewBuilder(relationships, meta)
+			builder.SetDAG(tt.dag)
+			tree, err := builder.Build("")
+			require.NoError(t, err)
+
+			require.Len(t, tree.Root.Children, 1)
+			main := tree.Root.Children[0]
+
+			var gotIn []string
+			for _, child := range main.Children {
+				for _, grandchild := range child.Children {
+					if grandchild.Name == "merge" {
+						gotIn = append(gotIn, child.Name)
+						assert.Equal(t, tt.wantAlsoFrom, grandchild.AlsoFrom)
+					}
+				}
+			}
+			assert.ElementsMatch(t, tt.wantMergeIn, gotIn)
+		})
+	}
+}
+
+func TestBuilder_Build_StableOrder(t *testing.T) {
+	relationships := map[string]map[string]bool{
+		"main":   {"feat-c": true, "feat-a": true, "feat-b": true, "feat-d": true},
+		"feat-a": {},
+		"feat-b": {},
+		"feat-c": {},
+		"feat-d": {},
+	}
+
+	for i := 0; i < 10; i++ {
+		tree, err := NewBuilder(relationships, nil).Build("feat-b")
+		require.NoError(t, err)
+
+		var got []string
+		for _, child := range tree.Root.Children[0].Children {
+			got = append(got, child.Name)
+		}
+		assert.Equal(t, []string{"feat-a", "feat-b", "feat-c", "feat-d"}, got)
+	}
+}
+
+func TestBuilder_Build_Commits(t *testing.T) {
+	relationships := map[string]map[string]bool{
+		"main":    {"feature": true},
+		"feature": {},
+	}
+
+	builder := NewBuilder(relationships, nil)
+	builder.SetCommits(map[string]Commit{
+		"main":    {Hash: "0123456789", Subject: "Release", AuthorName: "Jane", AuthorEmail: "jane@example.com"},
+		"feature": {Hash: "abcdef0123", Subject: "Add form", AuthorName: "John", AuthorEmail: "john@example.com"},
+	})
+	tree, err := builder.Build("feature")
+	require.NoError(t, err)
+
+	main := tree.Root.Children[0]
+	assert.Equal(t, "0123456789", main.Hash)
+	assert.Equal(t, "Release", main.Subject)
+	assert.Equal(t, "Jane", main.Author)
+	assert.Equal(t, "jane@example.com", main.AuthorEmail)
+
+	require.Len(t, main.Children, 1)
+	feature := main.Children[0]
+	assert.Equal(t, "feature", feature.Name)
+	assert.True(t, feature.IsCurrent)
+	assert.Equal(t, "feature", feature.CheckedOut())
+	assert.Equal(t, "abcdef0", feature.ShortHash())
+	assert.Equal(t, "Add form", feature.Subject)
+	assert.Equal(t, "John", feature.Author)
+	assert.Equal(t, "john@example.com", feature.AuthorEmail)
+}
+
 func TestBuilder_CyclicDependency(t *testing.T) {
 	relationships := map[string]map[string]bool{
 		"branch1": {"branch2": true},
@@ -201,4 +625,4 @@ func TestBuilder_findLeafNodes(t *testing.T) {
 	assert.True(t, leaves["feature"])
 	assert.True(t, leaves["develop"])
 	assert.False(t, leaves["master"])
-}
\ No newline at end of file
+}
@@ -0,0 +1,87 @@
+package tree
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+var (
+	ErrUnknownColorMode = errors.New("unknown color mode")
+)
+
+// ColorMode selects when the printed tree is colored.
+type ColorMode string
+
+const (
+	// color when writing to a terminal and NO_COLOR is not set
+	ColorAuto   ColorMode = "auto"
+	ColorAlways ColorMode = "always"
+	ColorNever  ColorMode = "never"
+)
+
+// branches without a commit for this long are dimmed like merged ones
+const staleAge = 90 * 24 * time.Hour
+
+// styles of the parts of the tree that deserve attention
+type colors struct {
+	current lipgloss.Style
+	dim     lipgloss.Style
+	// ages of the last commit under a day and under a week
+	fresh  lipgloss.Style
+	recent lipgloss.Style
+}
+
+// returns the colors for output in the given mode, or nil when it is not
+// colored
+func newColors(output io.Writer, mode ColorMode) (*colors, error) {
+	renderer := lipgloss.NewRenderer(output)
+	switch mode {
+	case "", ColorAuto:
+	case ColorAlways:
+		renderer.SetColorProfile(termenv.ANSI)
+	case ColorNever:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownColorMode, mode)
+	}
+	if renderer.ColorProfile() == termenv.Ascii {
+		return nil, nil
+	}
+
+	return &colors{
+		current: renderer.NewStyle().Foreground(lipgloss.Color("2")).Bold(true),
+		dim:     renderer.NewStyle().Faint(true),
+		fresh:   renderer.NewStyle().Foreground(lipgloss.Color("2")),
+		recent:  renderer.NewStyle().Foreground(lipgloss.Color("3")),
+	}, nil
+}
+
+// returns the style of a whole line, if it has one: the current branch is
+// highlighted, merged and stale branches are dimmed
+func (c *colors) line(current, merged bool, lastCommit time.Time) (lipgloss.Style, bool) {
+	switch {
+	case current:
+		return c.current, true
+	case merged, !lastCommit.IsZero() && time.Since(lastCommit) >= staleAge:
+		return c.dim, true
+	default:
+		return lipgloss.Style{}, false
+	}
+}
+
+// returns the style of the age of a commit, if it has one
+func (c *colors) age(lastCommit time.Time) (lipgloss.Style, bool) {
+	switch d := time.Since(lastCommit); {
+	case d < 24*time.Hour:
+		return c.fresh, true
+	case d < 7*24*time.Hour:
+		return c.recent, true
+	default:
+		return lipgloss.Style{}, false
+	}
+}
@@ -0,0 +1,80 @@
+package tree
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewColors(t *testing.T) {
+	tests := []struct {
+		mode    ColorMode
+		want    bool
+		wantErr error
+	}{
+		// a buffer is not a terminal
+		{mode: "", want: false},
+		{mode: ColorAuto, want: false},
+		{mode: ColorAlways, want: true},
+		{mode: ColorNever, want: false},
+		{mode: "sometimes", wantErr: ErrUnknownColorMode},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.mode), func(t *testing.T) {
+			c, err := newColors(&bytes.Buffer{}, tt.mode)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, c != nil)
+		})
+	}
+}
+
+func TestColors_Line(t *testing.T) {
+	c, err := newColors(&bytes.Buffer{}, ColorAlways)
+	require.NoError(t, err)
+
+	now := time.Now()
+	tests := []struct {
+		name       string
+		current    bool
+		merged     bool
+		lastCommit time.Time
+		want       bool
+	}{
+		{name: "current", current: true, lastCommit: now.Add(-200 * 24 * time.Hour), want: true},
+		{name: "merged", merged: true, want: true},
+		{name: "stale", lastCommit: now.Add(-100 * 24 * time.Hour), want: true},
+		{name: "active", lastCommit: now.Add(-10 * 24 * time.Hour), want: false},
+		{name: "unknown age", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, ok := c.line(tt.current, tt.merged, tt.lastCommit)
+			assert.Equal(t, tt.want, ok)
+		})
+	}
+}
+
+func TestColors_Age(t *testing.T) {
+	c, err := newColors(&bytes.Buffer{}, ColorAlways)
+	require.NoError(t, err)
+
+	style, ok := c.age(time.Now().Add(-time.Hour))
+	assert.True(t, ok)
+	assert.Equal(t, c.fresh.Render("1h ago"), style.Render("1h ago"))
+
+	style, ok = c.age(time.Now().Add(-3 * 24 * time.Hour))
+	assert.True(t, ok)
+	assert.Equal(t, c.recent.Render("3d ago"), style.Render("3d ago"))
+
+	_, ok = c.age(time.Now().Add(-10 * 24 * time.Hour))
+	assert.False(t, ok)
+}
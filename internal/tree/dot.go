@@ -0,0 +1,54 @@
+package tree
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteDOT writes t as a Graphviz digraph. The current branch is filled,
+// merged branches are grayed out, recorded links are bold and further
+// parents in DAG mode are dashed.
+func WriteDOT(w io.Writer, t *Tree) error {
+	nodes, edges := collectGraph(t)
+
+	var b strings.Builder
+	b.WriteString("digraph gittree {\n")
+	b.WriteString("  node [shape=box, style=rounded];\n")
+
+	for _, node := range nodes {
+		attrs := []string{"label=" + dotString(node.label...)}
+		if node.current {
+			attrs = append(attrs, `style="rounded,filled,bold"`, `fillcolor="lightblue"`)
+		}
+		if node.merged {
+			attrs = append(attrs, `color="gray50"`, `fontcolor="gray50"`)
+		}
+		fmt.Fprintf(&b, "  %s [%s];\n", dotString(node.name), strings.Join(attrs, ", "))
+	}
+
+	for _, edge := range edges {
+		fmt.Fprintf(&b, "  %s -> %s", dotString(edge.parent), dotString(edge.child))
+		switch {
+		case edge.also:
+			b.WriteString(" [style=dashed]")
+		case edge.recorded:
+			b.WriteString(" [style=bold]")
+		}
+		b.WriteString(";\n")
+	}
+
+	b.WriteString("}\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// quotes lines as a DOT string, one line each
+func dotString(lines ...string) string {
+	escaped := make([]string, len(lines))
+	for i, line := range lines {
+		escaped[i] = strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(line)
+	}
+	return `"` + strings.Join(escaped, `\n`) + `"`
+}
@@ -0,0 +1,77 @@
+package tree
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// main with feat/a, which carries the current branch as an alias, a recorded
+// feat/b and a merged feat/c that also descends from feat/a
+func graphTestTree() *Tree {
+	return &Tree{
+		Root: &Node{
+			Name: rootNodeName,
+			Children: []*Node{
+				{
+					Name: "main",
+					Children: []*Node{
+						{Name: "feat/a", Aliases: []string{"feat/x"}, IsCurrent: true, CurrentAlias: "feat/x", Ahead: 2, Children: []*Node{}},
+						{Name: "feat/b", Recorded: true, Children: []*Node{
+							{Name: "feat/c", Merged: true, AlsoFrom: []string{"feat/a"}, Children: []*Node{}},
+						}},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestWriteDOT(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteDOT(&buf, graphTestTree()))
+
+	want := `digraph gittree {
+  node [shape=box, style=rounded];
+  "main" [label="main"];
+  "feat/a" [label="feat/a, feat/x\n+2", style="rounded,filled,bold", fillcolor="lightblue"];
+  "feat/b" [label="feat/b"];
+  "feat/c" [label="feat/c\n[merged]", color="gray50", fontcolor="gray50"];
+  "main" -> "feat/a";
+  "main" -> "feat/b" [style=bold];
+  "feat/b" -> "feat/c";
+  "feat/a" -> "feat/c" [style=dashed];
+}
+`
+	assert.Equal(t, want, buf.String())
+}
+
+func TestWriteDOT_Escaping(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteDOT(&buf, &Tree{Root: &Node{Name: `say"hi\`}}))
+	assert.Contains(t, buf.String(), `"say\"hi\\" [label="say\"hi\\"];`)
+}
+
+func TestWriteDOT_DetachedHead(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteDOT(&buf, &Tree{Root: &Node{Name: "HEAD", IsDetached: true, Hash: "abc1234def5678"}}))
+	assert.Contains(t, buf.String(), `"HEAD" [label="HEAD (detached at abc1234)"];`)
+}
+
+func TestCollectGraph_RepeatedBranch(t *testing.T) {
+	// without --dag a branch appears under each of its parents
+	shared := &Node{Name: "merge"}
+	tree := &Tree{Root: &Node{Name: rootNodeName, Children: []*Node{
+		{Name: "feat/a", Children: []*Node{shared}},
+		{Name: "feat/b", Children: []*Node{shared}},
+	}}}
+
+	nodes, edges := collectGraph(tree)
+	assert.Len(t, nodes, 3)
+	assert.Equal(t, []graphEdge{
+		{parent: "feat/a", child: "merge"},
+		{parent: "feat/b", child: "merge"},
+	}, edges)
+}
@@ -2,92 +2,106 @@ package tree
 
 import (
 	"fmt"
-
-	"github.com/mucansever/gittree/internal/timefmt"
+	"time"
 )
 
+// Item is a line of the drawn tree, along with the branch it shows.
 type Item struct {
 	BranchName string
 	Text       string
+	// other branches at the same commit, which can be checked out instead
+	Aliases []string
+	// the checked out branch is BranchName, or the alias named by CurrentAlias
+	IsCurrent    bool
+	CurrentAlias string
+	IsRemote     bool
+	IsDetached   bool
+	Hash         string
+	LastCommit   time.Time
+	Merged       bool
+	SquashMerged bool
+	// short hash, commit date, author and subject, shown in verbose mode
+	Columns []string
+	// levels below the root, which is at 0
+	Depth int
+	// number of children of the branch, and of descendants left out when
+	// it is folded
+	Children int
+	Hidden   int
 }
 
-func Flatten(t *Tree) []Item {
-	if t == nil || t.Root == nil {
-		return nil
-	}
-	return flattenNode(t.Root, "", true)
+// IsRoot reports whether the item is the line standing for the whole tree.
+func (i Item) IsRoot() bool {
+	return i.BranchName == rootNodeName
 }
 
-func flattenNode(node *Node, prefix string, isLast bool) []Item {
-	var items []Item
-
-	displayName := node.Name
-	if !node.LastCommit.IsZero() {
-		displayName = fmt.Sprintf("%s (%s ago)", node.Name, timefmt.RelativeTime(node.LastCommit))
+func newItem(node *Node, text string) Item {
+	return Item{
+		BranchName:   node.Name,
+		Text:         text,
+		Aliases:      node.Aliases,
+		IsCurrent:    node.IsCurrent,
+		CurrentAlias: node.CurrentAlias,
+		IsRemote:     node.IsRemote,
+		IsDetached:   node.IsDetached,
+		Hash:         node.Hash,
+		LastCommit:   node.LastCommit,
+		Merged:       node.Merged,
+		SquashMerged: node.SquashMerged,
+		Columns:      verboseColumns(node),
+		Children:     len(node.Children),
 	}
+}
 
-	connector := ""
+// Flatten lists the nodes of t as lines of the drawn tree, labelled with
+// format, or the default label when format is nil.
+func Flatten(t *Tree, format *Format) []Item {
+	return FlattenFolded(t, format, nil)
+}
 
-	lineText := ""
-	if prefix != "" {
-		connector = "├── "
-		if isLast {
-			connector = "└── "
-		}
-		lineText = prefix + connector + displayName
-	} else {
-		lineText = displayName
+// FlattenFolded is like Flatten, but leaves out the descendants of the
+// branches in folded and ends their lines with the number left out, as in
+// "feature [+3]".
+func FlattenFolded(t *Tree, format *Format, folded map[string]bool) []Item {
+	if t == nil || t.Root == nil {
+		return nil
 	}
+	f := flattener{format: format, folded: folded}
+	return f.flatten(t.Root, "", "", 0)
+}
 
-	items = append(items, Item{
-		BranchName: node.Name,
-		Text:       lineText,
-	})
+type flattener struct {
+	format *Format
+	folded map[string]bool
+}
 
-	childPrefix := prefix
-	if prefix == "" {
-		childPrefix = ""
-	} else {
-		if isLast {
-			childPrefix += "    "
-		} else {
-			childPrefix += "│   "
-		}
+// lists node and its descendants; lead is drawn before the node's label and
+// prefix before the lines of its children
+func (f flattener) flatten(node *Node, lead, prefix string, depth int) []Item {
+	item := newItem(node, lead+f.format.Label(node))
+	item.Depth = depth
+	if f.folded[node.Name] && len(node.Children) > 0 {
+		item.Hidden = countDescendants(node)
+		item.Text += fmt.Sprintf(" [+%d]", item.Hidden)
+		return []Item{item}
 	}
 
+	items := []Item{item}
 	for i, child := range node.Children {
-		isChildLast := i == len(node.Children)-1
-
-		if prefix == "" {
-			childDisplayName := child.Name
-			if !child.LastCommit.IsZero() {
-				childDisplayName = fmt.Sprintf("%s (%s ago)", child.Name, timefmt.RelativeTime(child.LastCommit))
-			}
-
-			childConnector := "├── "
-			if isChildLast {
-				childConnector = "└── "
-			}
-
-			items = append(items, Item{
-				BranchName: child.Name,
-				Text:       childConnector + childDisplayName,
-			})
-
-			grandchildPrefix := "│   "
-			if isChildLast {
-				grandchildPrefix = "    "
-			}
-
-			for j, grandchild := range child.Children {
-				isGrandchildLast := j == len(child.Children)-1
-				items = append(items, flattenNode(grandchild, grandchildPrefix, isGrandchildLast)...)
-			}
-
-		} else {
-			items = append(items, flattenNode(child, childPrefix, isChildLast)...)
+		isLast := i == len(node.Children)-1
+		childPrefix := prefix + "│   "
+		if isLast {
+			childPrefix = prefix + "    "
 		}
+		items = append(items, f.flatten(child, prefix+connector(child, isLast), childPrefix, depth+1)...)
 	}
-
 	return items
 }
+
+func countDescendants(node *Node) int {
+	count := 0
+	for _, child := range node.Children {
+		count += 1 + countDescendants(child)
+	}
+	return count
+}
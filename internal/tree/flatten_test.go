@@ -5,13 +5,16 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestFlatten(t *testing.T) {
 	now := time.Now()
 
 	nodeFeature1 := NewNode("feature1", time.Time{})
+	nodeFeature1.Ahead = 3
 	nodeFeature2 := NewNode("feature2", time.Time{})
+	nodeFeature2.Merged = true
 
 	nodeMaster := NewNode("master", now.Add(-2*time.Hour))
 	nodeMaster.AddChild(nodeFeature1)
@@ -22,7 +25,7 @@ func TestFlatten(t *testing.T) {
 
 	tree := &Tree{Root: nodeRoot}
 
-	items := Flatten(tree)
+	items := Flatten(tree, nil)
 
 	assert.Len(t, items, 4)
 
@@ -34,12 +37,96 @@ func TestFlatten(t *testing.T) {
 	assert.Contains(t, items[1].Text, "└── ")
 
 	assert.Equal(t, "feature1", items[2].BranchName)
-	assert.Contains(t, items[2].Text, "feature1")
+	assert.Contains(t, items[2].Text, "feature1 (+3)")
 	assert.Contains(t, items[2].Text, "├── ")
 	assert.Contains(t, items[2].Text, "    ")
 
 	assert.Equal(t, "feature2", items[3].BranchName)
-	assert.Contains(t, items[3].Text, "feature2")
+	assert.Contains(t, items[3].Text, "feature2 [merged]")
+	assert.True(t, items[3].Merged)
+	assert.False(t, items[2].Merged)
 	assert.Contains(t, items[3].Text, "└── ")
 	assert.Contains(t, items[3].Text, "    ")
 }
+
+func TestFlatten_SquashMerged(t *testing.T) {
+	node := NewNode("feature", time.Time{})
+	node.SquashMerged = true
+
+	root := NewNode("main", time.Time{})
+	root.AddChild(node)
+
+	items := Flatten(&Tree{Root: root}, nil)
+
+	require.Len(t, items, 2)
+	assert.Equal(t, "└── feature [squash-merged]", items[1].Text)
+	assert.True(t, items[1].SquashMerged)
+	assert.False(t, items[1].Merged)
+}
+
+func TestFlatten_Aliases(t *testing.T) {
+	node := NewNode("feat/x", time.Time{})
+	node.Aliases = []string{"feat/y"}
+	node.IsCurrent = true
+	node.CurrentAlias = "feat/y"
+
+	root := NewNode("main", time.Time{})
+	root.AddChild(node)
+
+	items := Flatten(&Tree{Root: root}, nil)
+
+	require.Len(t, items, 2)
+	assert.Equal(t, "feat/x", items[1].BranchName)
+	assert.Equal(t, []string{"feat/y"}, items[1].Aliases)
+	assert.Equal(t, "└── feat/x, feat/y*", items[1].Text)
+	assert.True(t, items[1].IsCurrent)
+	assert.Equal(t, "feat/y", items[1].CurrentAlias)
+}
+
+func TestFlatten_Format(t *testing.T) {
+	format, err := ParseFormat("{{.Name}}: {{.Subject}}")
+	require.NoError(t, err)
+
+	root := NewNode(".", time.Time{})
+	master := NewNode("master", time.Time{})
+	master.IsCurrent = true
+	master.Subject = "Initial commit"
+	root.AddChild(master)
+
+	items := Flatten(&Tree{Root: root}, format)
+	require.Len(t, items, 2)
+	assert.Equal(t, "└── master: Initial commit", items[1].Text)
+	assert.Equal(t, "master", items[1].BranchName)
+	assert.True(t, items[1].IsCurrent)
+}
+
+func TestFlattenFolded(t *testing.T) {
+	leaf := NewNode("feat/a2", time.Time{})
+	featA := NewNode("feat/a", time.Time{})
+	featA.AddChild(leaf)
+	featB := NewNode("feat/b", time.Time{})
+	main := NewNode("main", time.Time{})
+	main.AddChild(featA)
+	main.AddChild(featB)
+	root := NewNode(".", time.Time{})
+	root.AddChild(main)
+	tree := &Tree{Root: root}
+
+	items := FlattenFolded(tree, nil, nil)
+	require.Len(t, items, 5)
+	assert.Equal(t, []int{0, 1, 2, 3, 2}, []int{items[0].Depth, items[1].Depth, items[2].Depth, items[3].Depth, items[4].Depth})
+	assert.Equal(t, 1, items[2].Children)
+	assert.Zero(t, items[2].Hidden)
+
+	items = FlattenFolded(tree, nil, map[string]bool{"feat/a": true, "feat/b": true})
+	require.Len(t, items, 4)
+	assert.Equal(t, "    ├── feat/a [+1]", items[2].Text)
+	assert.Equal(t, 1, items[2].Hidden)
+	// nothing to fold below a leaf
+	assert.Equal(t, "    └── feat/b", items[3].Text)
+
+	items = FlattenFolded(tree, nil, map[string]bool{"main": true})
+	require.Len(t, items, 2)
+	assert.Equal(t, "└── main [+3]", items[1].Text)
+	assert.Equal(t, 3, items[1].Hidden)
+}
@@ -0,0 +1,117 @@
+package tree
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/mucansever/gittree/internal/timefmt"
+)
+
+var (
+	ErrInvalidFormat = errors.New("invalid format")
+)
+
+// named formats accepted by ParseFormat in place of a template
+var formatPresets = map[string]string{
+	"short":   `{{.Name}}{{if .Current}}*{{end}}{{range .Aliases}}, {{.}}{{end}}`,
+	"oneline": `{{.Name}}{{if .Current}}*{{end}} {{.ShortHash}} {{.Subject}}`,
+	"full":    `{{.Name}}{{if .Current}}*{{end}}{{range .Aliases}}, {{.}}{{end}} {{.ShortHash}} {{.Author}}{{if .Relative}}, {{.Relative}}{{end}} (+{{.Ahead}} -{{.Behind}}) {{.Subject}}`,
+}
+
+// Format renders the label of a node from a text/template. A nil Format
+// renders the default "name (3h ago, +4 -2)" label.
+type Format struct {
+	tmpl *template.Template
+}
+
+// FormatFields are the fields available to a format template.
+type FormatFields struct {
+	// branch name, without the current branch marker
+	Name string
+	// other branches at the same commit
+	Aliases []string
+	// the branch, or one of its aliases, is checked out
+	Current   bool
+	Hash      string
+	ShortHash string
+	// first line of the tip commit's message
+	Subject     string
+	Author      string
+	AuthorEmail string
+	// "3h ago", empty when the commit time is unknown
+	Relative   string
+	LastCommit time.Time
+	Ahead      int
+	Behind     int
+	Merged     bool
+	// merged into the trunk as different commits
+	SquashMerged bool
+	// the parent was recorded rather than inferred
+	Recorded bool
+	AlsoFrom []string
+}
+
+// ParseFormat returns the preset called spec, or parses spec as a
+// text/template over FormatFields. An empty spec returns nil.
+func ParseFormat(spec string) (*Format, error) {
+	if spec == "" {
+		return nil, nil
+	}
+	if preset, ok := formatPresets[spec]; ok {
+		spec = preset
+	}
+
+	tmpl, err := template.New("format").Parse(spec)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidFormat, err)
+	}
+	// catch unknown fields now rather than on every node
+	if err := tmpl.Execute(&bytes.Buffer{}, FormatFields{}); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidFormat, err)
+	}
+
+	return &Format{tmpl: tmpl}, nil
+}
+
+// Label renders node, falling back to the default label if the template
+// fails on it.
+func (f *Format) Label(node *Node) string {
+	if f == nil || node.Name == rootNodeName {
+		return formatLabel(node)
+	}
+
+	var buf bytes.Buffer
+	if err := f.tmpl.Execute(&buf, newFormatFields(node)); err != nil {
+		return formatLabel(node)
+	}
+	// a label is a single line of the tree
+	return strings.ReplaceAll(buf.String(), "\n", " ")
+}
+
+func newFormatFields(node *Node) FormatFields {
+	fields := FormatFields{
+		Name:         displayName(node),
+		Aliases:      node.Aliases,
+		Current:      node.IsCurrent,
+		Hash:         node.Hash,
+		ShortHash:    node.ShortHash(),
+		Subject:      node.Subject,
+		Author:       node.Author,
+		AuthorEmail:  node.AuthorEmail,
+		LastCommit:   node.LastCommit,
+		Ahead:        node.Ahead,
+		Behind:       node.Behind,
+		Merged:       node.Merged,
+		SquashMerged: node.SquashMerged,
+		Recorded:     node.Recorded,
+		AlsoFrom:     node.AlsoFrom,
+	}
+	if !node.LastCommit.IsZero() {
+		fields.Relative = timefmt.RelativeTime(node.LastCommit) + " ago"
+	}
+	return fields
+}
@@ -0,0 +1,56 @@
+package tree
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormat_Label(t *testing.T) {
+	node := &Node{
+		Name:       "feat/a",
+		IsCurrent:  true,
+		Aliases:    []string{"feat/b"},
+		Hash:       "0123456789abcdef0123456789abcdef01234567",
+		Subject:    "Add feature",
+		Author:     "Test User",
+		LastCommit: time.Now().Add(-3 * time.Hour),
+		Ahead:      2,
+		Behind:     1,
+	}
+
+	tests := []struct {
+		spec string
+		want string
+	}{
+		{"", "feat/a*, feat/b (3h ago, +2 -1, diverged)"},
+		{"short", "feat/a*, feat/b"},
+		{"oneline", "feat/a* 0123456 Add feature"},
+		{"full", "feat/a*, feat/b 0123456 Test User, 3h ago (+2 -1) Add feature"},
+		{"{{.Name}} by {{.Author}}{{if .Current}} (current){{end}}", "feat/a by Test User (current)"},
+		{"{{.Name}}\n{{.Ahead}}", "feat/a 2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			format, err := ParseFormat(tt.spec)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, format.Label(node))
+		})
+	}
+}
+
+func TestFormat_RootKeepsDefaultLabel(t *testing.T) {
+	format, err := ParseFormat("oneline")
+	require.NoError(t, err)
+	assert.Equal(t, ".", format.Label(&Node{Name: rootNodeName}))
+}
+
+func TestParseFormat_Invalid(t *testing.T) {
+	for _, spec := range []string{"{{.Name", "{{.Nope}}"} {
+		_, err := ParseFormat(spec)
+		assert.ErrorIs(t, err, ErrInvalidFormat, spec)
+	}
+}
@@ -0,0 +1,89 @@
+package tree
+
+import "strings"
+
+// a branch as drawn by the graph writers
+type graphNode struct {
+	// branch name without the current branch marker
+	name string
+	// lines of the node's label
+	label   []string
+	current bool
+	merged  bool
+}
+
+// a link between two branches as drawn by the graph writers
+type graphEdge struct {
+	parent   string
+	child    string
+	recorded bool
+	// a further parent of the child in DAG mode
+	also bool
+}
+
+// collects every branch of t once, in the order it is first met, along with
+// the links between them. A branch listed under several parents gets a link
+// from each of them.
+func collectGraph(t *Tree) ([]graphNode, []graphEdge) {
+	var nodes []graphNode
+	var edges []graphEdge
+	seenNodes := make(map[string]bool)
+	seenEdges := make(map[graphEdge]bool)
+
+	addEdge := func(edge graphEdge) {
+		if !seenEdges[edge] {
+			seenEdges[edge] = true
+			edges = append(edges, edge)
+		}
+	}
+
+	var visit func(node *Node, parent string)
+	visit = func(node *Node, parent string) {
+		name := node.Name
+
+		if !seenNodes[name] {
+			seenNodes[name] = true
+
+			label := []string{strings.Join(append([]string{displayName(node)}, node.Aliases...), ", ")}
+			if details := labelDetails(node); len(details) > 0 {
+				label = append(label, strings.Join(details, ", "))
+			}
+			if node.Merged {
+				label = append(label, "[merged]")
+			} else if node.SquashMerged {
+				label = append(label, "[squash-merged]")
+			}
+
+			nodes = append(nodes, graphNode{
+				name:    name,
+				label:   label,
+				current: node.IsCurrent,
+				merged:  node.Merged || node.SquashMerged,
+			})
+		}
+
+		if parent != "" {
+			addEdge(graphEdge{parent: parent, child: name, recorded: node.Recorded})
+		}
+		for _, other := range node.AlsoFrom {
+			addEdge(graphEdge{parent: other, child: name, also: true})
+		}
+
+		for _, child := range node.Children {
+			visit(child, name)
+		}
+	}
+
+	if t == nil || t.Root == nil {
+		return nil, nil
+	}
+	if t.Root.Name == rootNodeName || t.Root.Name == "" {
+		for _, root := range t.Root.Children {
+			visit(root, "")
+		}
+	} else {
+		visit(t.Root, "")
+	}
+
+	return nodes, edges
+}
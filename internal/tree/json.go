@@ -0,0 +1,115 @@
+package tree
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// JSONSchemaVersion is the version of the document written by WriteJSON. It
+// changes when a field is removed or changes meaning; new fields may be
+// added without changing it.
+const JSONSchemaVersion = 1
+
+// JSONTree is the document written by WriteJSON.
+type JSONTree struct {
+	SchemaVersion int `json:"schemaVersion"`
+	// name of the checked out branch, "HEAD" when HEAD is detached
+	Current string `json:"current,omitempty"`
+	// branches without a parent, usually the trunk
+	Roots []JSONNode `json:"roots"`
+}
+
+// JSONNode is a branch in the JSON output.
+type JSONNode struct {
+	Name string `json:"name"`
+	// set on the checked out branch, or the node standing in for it
+	Current bool `json:"current"`
+	// a remote-tracking branch, or the detached HEAD, which is named "HEAD"
+	Remote   bool   `json:"remote"`
+	Detached bool   `json:"detached"`
+	Hash     string `json:"hash,omitempty"`
+	// first line of the tip commit's message and its author
+	Subject     string `json:"subject,omitempty"`
+	Author      string `json:"author,omitempty"`
+	AuthorEmail string `json:"authorEmail,omitempty"`
+	// RFC 3339 time of the tip commit
+	LastCommit string `json:"lastCommit,omitempty"`
+	// RFC 3339 time the branch was created, when the reflog knows it
+	Created string `json:"created,omitempty"`
+	// other branches at the same commit
+	Aliases []string `json:"aliases,omitempty"`
+	// commits the branch has that its parent does not, and vice versa
+	Ahead  int `json:"ahead"`
+	Behind int `json:"behind"`
+	// contained in the trunk, or landed there as different commits
+	Merged       bool `json:"merged"`
+	SquashMerged bool `json:"squashMerged"`
+	// the parent was recorded by the user rather than inferred
+	Recorded bool `json:"recorded"`
+	// further parents in DAG mode
+	AlsoFrom []string   `json:"alsoFrom,omitempty"`
+	Children []JSONNode `json:"children"`
+}
+
+// WriteJSON writes t as an indented JSONTree document.
+func WriteJSON(w io.Writer, t *Tree) error {
+	doc := JSONTree{
+		SchemaVersion: JSONSchemaVersion,
+		Roots:         []JSONNode{},
+	}
+
+	if t != nil && t.Root != nil {
+		roots := []*Node{t.Root}
+		if t.Root.Name == rootNodeName || t.Root.Name == "" {
+			roots = t.Root.Children
+		}
+		for _, root := range roots {
+			doc.Roots = append(doc.Roots, toJSONNode(root, &doc.Current))
+		}
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(doc)
+}
+
+// converts node and its descendants, storing the name of the current branch
+// in current when it comes across it
+func toJSONNode(node *Node, current *string) JSONNode {
+	if node.IsCurrent {
+		*current = node.CheckedOut()
+	}
+
+	out := JSONNode{
+		Name:         node.Name,
+		Current:      node.IsCurrent,
+		Remote:       node.IsRemote,
+		Detached:     node.IsDetached,
+		Hash:         node.Hash,
+		Subject:      node.Subject,
+		Author:       node.Author,
+		AuthorEmail:  node.AuthorEmail,
+		LastCommit:   formatJSONTime(node.LastCommit),
+		Created:      formatJSONTime(node.Created),
+		Aliases:      node.Aliases,
+		Ahead:        node.Ahead,
+		Behind:       node.Behind,
+		Merged:       node.Merged,
+		SquashMerged: node.SquashMerged,
+		Recorded:     node.Recorded,
+		AlsoFrom:     node.AlsoFrom,
+		Children:     make([]JSONNode, 0, len(node.Children)),
+	}
+	for _, child := range node.Children {
+		out.Children = append(out.Children, toJSONNode(child, current))
+	}
+	return out
+}
+
+func formatJSONTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
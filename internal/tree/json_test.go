@@ -0,0 +1,83 @@
+package tree
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteJSON(t *testing.T) {
+	commit := time.Date(2024, 5, 1, 9, 30, 0, 0, time.FixedZone("", 2*60*60))
+	tree := &Tree{
+		Root: &Node{
+			Name: rootNodeName,
+			Children: []*Node{
+				{
+					Name:        "main",
+					Hash:        "abc123",
+					Subject:     "Release",
+					Author:      "Jane",
+					AuthorEmail: "jane@example.com",
+					LastCommit:  commit,
+					Children: []*Node{
+						{Name: "feat/a", IsCurrent: true, Ahead: 2, Behind: 1, Recorded: true, Children: []*Node{}},
+						{Name: "feat/b", Aliases: []string{"feat/c"}, Merged: true, AlsoFrom: []string{"feat/a"}, Children: []*Node{}},
+						{Name: "origin/feat/d", IsRemote: true, Children: []*Node{}},
+						{Name: "HEAD", IsDetached: true, Hash: "def456", Children: []*Node{}},
+					},
+				},
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteJSON(&buf, tree))
+
+	var got JSONTree
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &got))
+
+	assert.Equal(t, JSONTree{
+		SchemaVersion: JSONSchemaVersion,
+		Current:       "feat/a",
+		Roots: []JSONNode{
+			{
+				Name:        "main",
+				Hash:        "abc123",
+				Subject:     "Release",
+				Author:      "Jane",
+				AuthorEmail: "jane@example.com",
+				LastCommit:  "2024-05-01T09:30:00+02:00",
+				Children: []JSONNode{
+					{Name: "feat/a", Current: true, Ahead: 2, Behind: 1, Recorded: true, Children: []JSONNode{}},
+					{Name: "feat/b", Aliases: []string{"feat/c"}, Merged: true, AlsoFrom: []string{"feat/a"}, Children: []JSONNode{}},
+					{Name: "origin/feat/d", Remote: true, Children: []JSONNode{}},
+					{Name: "HEAD", Detached: true, Hash: "def456", Children: []JSONNode{}},
+				},
+			},
+		},
+	}, got)
+}
+
+func TestWriteJSON_CurrentAlias(t *testing.T) {
+	tree := &Tree{Root: &Node{Name: "main", Aliases: []string{"no-commit"}, IsCurrent: true, CurrentAlias: "no-commit"}}
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteJSON(&buf, tree))
+
+	var got JSONTree
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &got))
+	assert.Equal(t, "no-commit", got.Current)
+	require.Len(t, got.Roots, 1)
+	assert.True(t, got.Roots[0].Current)
+	assert.Equal(t, []string{"no-commit"}, got.Roots[0].Aliases)
+}
+
+func TestWriteJSON_Empty(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteJSON(&buf, nil))
+	assert.JSONEq(t, `{"schemaVersion": 1, "roots": []}`, buf.String())
+}
@@ -0,0 +1,70 @@
+package tree
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteMermaid writes t as a Mermaid flowchart, styled like WriteDOT: the
+// current branch is highlighted, merged branches are grayed out, recorded
+// links are thick and further parents in DAG mode are dotted.
+func WriteMermaid(w io.Writer, t *Tree) error {
+	nodes, edges := collectGraph(t)
+
+	var b strings.Builder
+	b.WriteString("flowchart TD\n")
+
+	// branch names are not valid Mermaid ids, so nodes are numbered
+	ids := make(map[string]string, len(nodes))
+	var current, merged []string
+	for i, node := range nodes {
+		id := fmt.Sprintf("n%d", i)
+		ids[node.name] = id
+		fmt.Fprintf(&b, "  %s[\"%s\"]\n", id, mermaidLabel(node.label))
+
+		if node.current {
+			current = append(current, id)
+		}
+		if node.merged {
+			merged = append(merged, id)
+		}
+	}
+
+	for _, edge := range edges {
+		parent, ok := ids[edge.parent]
+		if !ok {
+			continue
+		}
+		arrow := "-->"
+		switch {
+		case edge.also:
+			arrow = "-.->"
+		case edge.recorded:
+			arrow = "==>"
+		}
+		fmt.Fprintf(&b, "  %s %s %s\n", parent, arrow, ids[edge.child])
+	}
+
+	if len(current) > 0 {
+		b.WriteString("  classDef current fill:#add8e6,stroke:#333,stroke-width:2px\n")
+		fmt.Fprintf(&b, "  class %s current\n", strings.Join(current, ","))
+	}
+	if len(merged) > 0 {
+		b.WriteString("  classDef merged color:#7f7f7f,stroke:#7f7f7f\n")
+		fmt.Fprintf(&b, "  class %s merged\n", strings.Join(merged, ","))
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// escapes the lines of a label for a quoted Mermaid node text
+func mermaidLabel(lines []string) string {
+	replacer := strings.NewReplacer(`"`, "#quot;", "<", "#lt;", ">", "#gt;")
+	escaped := make([]string, len(lines))
+	for i, line := range lines {
+		escaped[i] = replacer.Replace(line)
+	}
+	return strings.Join(escaped, "<br/>")
+}
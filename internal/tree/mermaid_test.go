@@ -0,0 +1,40 @@
+package tree
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteMermaid(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteMermaid(&buf, graphTestTree()))
+
+	want := `flowchart TD
+  n0["main"]
+  n1["feat/a, feat/x<br/>+2"]
+  n2["feat/b"]
+  n3["feat/c<br/>[merged]"]
+  n0 --> n1
+  n0 ==> n2
+  n2 --> n3
+  n1 -.-> n3
+  classDef current fill:#add8e6,stroke:#333,stroke-width:2px
+  class n1 current
+  classDef merged color:#7f7f7f,stroke:#7f7f7f
+  class n3 merged
+`
+	assert.Equal(t, want, buf.String())
+}
+
+func TestMermaidLabel(t *testing.T) {
+	assert.Equal(t, "a #quot;b#quot;<br/>#lt;c#gt;", mermaidLabel([]string{`a "b"`, "<c>"}))
+}
+
+func TestWriteMermaid_Empty(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteMermaid(&buf, nil))
+	assert.Equal(t, "flowchart TD\n", buf.String())
+}
@@ -8,6 +8,33 @@ type Node struct {
 	Name       string
 	Children   []*Node
 	LastCommit time.Time
+	// other branches pointing at the same commit as Name
+	Aliases []string
+	// the checked out branch is Name, or the alias named by CurrentAlias
+	IsCurrent    bool
+	CurrentAlias string
+	// a remote-tracking branch, or HEAD detached from any branch
+	IsRemote   bool
+	IsDetached bool
+	// when the branch was created, if known
+	Created time.Time
+	// full hash of the commit the branch points at
+	Hash string
+	// first line of the tip commit's message and its author
+	Subject     string
+	Author      string
+	AuthorEmail string
+	// commits the branch has that its tree parent does not, and vice versa
+	Ahead  int
+	Behind int
+	// the branch is fully contained in the trunk
+	Merged bool
+	// the branch's changes landed in the trunk as different commits
+	SquashMerged bool
+	// the link to the parent was recorded by the user rather than inferred
+	Recorded bool
+	// further parents of the branch, drawn as cross-links in DAG mode
+	AlsoFrom []string
 }
 
 type Tree struct {
@@ -22,6 +49,42 @@ func NewNode(name string, lastCommit time.Time) *Node {
 	}
 }
 
+// CheckedOut returns the name of the checked out branch if the node stands
+// for it, either its own name or that of an alias, and "" otherwise.
+func (n *Node) CheckedOut() string {
+	switch {
+	case !n.IsCurrent:
+		return ""
+	case n.CurrentAlias != "":
+		return n.CurrentAlias
+	default:
+		return n.Name
+	}
+}
+
+// ShortHash returns the abbreviated hash of the branch's tip commit.
+func (n *Node) ShortHash() string {
+	if len(n.Hash) > 7 {
+		return n.Hash[:7]
+	}
+	return n.Hash
+}
+
 func (n *Node) AddChild(child *Node) {
 	n.Children = append(n.Children, child)
 }
+
+// Walk calls fn for every node of the tree, parents before their children.
+func (t *Tree) Walk(fn func(node *Node)) {
+	if t == nil || t.Root == nil {
+		return
+	}
+	t.Root.walk(fn)
+}
+
+func (n *Node) walk(fn func(node *Node)) {
+	fn(n)
+	for _, child := range n.Children {
+		child.walk(fn)
+	}
+}
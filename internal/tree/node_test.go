@@ -26,4 +26,22 @@ func TestNode_AddChild(t *testing.T) {
 	assert.Len(t, parent.Children, 2)
 	assert.Equal(t, child1, parent.Children[0])
 	assert.Equal(t, child2, parent.Children[1])
-}
\ No newline at end of file
+}
+func TestTree_Walk(t *testing.T) {
+	root := NewNode(".", time.Time{})
+	main := NewNode("main", time.Time{})
+	feature := NewNode("feature", time.Time{})
+	fix := NewNode("fix", time.Time{})
+	root.AddChild(main)
+	main.AddChild(feature)
+	root.AddChild(fix)
+
+	var visited []string
+	(&Tree{Root: root}).Walk(func(node *Node) {
+		visited = append(visited, node.Name)
+	})
+	assert.Equal(t, []string{".", "main", "feature", "fix"}, visited)
+
+	// nil trees are a no-op
+	(&Tree{}).Walk(func(node *Node) { t.Fail() })
+}
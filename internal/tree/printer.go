@@ -3,12 +3,17 @@ package tree
 import (
 	"fmt"
 	"io"
+	"strings"
 
 	"github.com/mucansever/gittree/internal/timefmt"
 )
 
 type Printer struct {
-	output io.Writer
+	output  io.Writer
+	format  *Format
+	verbose bool
+	// nil when the output is not colored
+	colors *colors
 }
 
 func NewPrinter(output io.Writer) *Printer {
@@ -19,6 +24,19 @@ func (p *Printer) Print(t *Tree) {
 	if t == nil || t.Root == nil {
 		return
 	}
+	if p.verbose {
+		items := Flatten(t, p.format)
+		for i, line := range AlignColumns(items) {
+			if p.colors != nil {
+				item := items[i]
+				if style, ok := p.colors.line(item.IsCurrent, item.Merged || item.SquashMerged, item.LastCommit); ok {
+					line = style.Render(line)
+				}
+			}
+			fmt.Fprintf(p.output, "%s\n", line)
+		}
+		return
+	}
 	if t.Root.Name == "" {
 		for _, child := range t.Root.Children {
 			fmt.Fprintf(p.output, "%s\n", p.formatName(child))
@@ -31,23 +49,142 @@ func (p *Printer) Print(t *Tree) {
 	}
 }
 
+// SetFormat renders node labels with f instead of the default label.
+func (p *Printer) SetFormat(f *Format) {
+	p.format = f
+}
+
+// SetVerbose adds the short hash, commit date, author and subject of every
+// branch in aligned columns after the tree.
+func (p *Printer) SetVerbose(verbose bool) {
+	p.verbose = verbose
+}
+
+// SetColor colors the current branch, dims merged and stale branches and
+// colors ages of recent commits, depending on mode and the output.
+func (p *Printer) SetColor(mode ColorMode) error {
+	c, err := newColors(p.output, mode)
+	if err != nil {
+		return err
+	}
+	p.colors = c
+	return nil
+}
+
 func (p *Printer) formatName(node *Node) string {
-	displayName := node.Name
+	if p.colors == nil {
+		return p.format.Label(node)
+	}
+	if style, ok := p.colors.line(node.IsCurrent, node.Merged || node.SquashMerged, node.LastCommit); ok {
+		return style.Render(p.format.Label(node))
+	}
+	if p.format == nil && node.Name != rootNodeName {
+		return colorLabel(node, p.colors)
+	}
+	return p.format.Label(node)
+}
+
+// draws the edge from a node's parent; edges recorded by the user are drawn
+// with double lines to set them apart from inferred ones
+func connector(node *Node, isLast bool) string {
+	switch {
+	case node.Recorded && isLast:
+		return "└══ "
+	case node.Recorded:
+		return "├══ "
+	case isLast:
+		return "└── "
+	default:
+		return "├── "
+	}
+}
+
+// renders a node as "name* (3h ago, +4 -2, diverged) (also from x) [merged]",
+// omitting empty parts; the checked out branch is marked with an asterisk
+func formatLabel(node *Node) string {
+	return colorLabel(node, nil)
+}
+
+// renders the label of formatLabel, coloring the age when c is set
+func colorLabel(node *Node, c *colors) string {
+	details := labelDetails(node)
+	if c != nil && !node.LastCommit.IsZero() {
+		// the age comes first
+		if style, ok := c.age(node.LastCommit); ok {
+			details[0] = style.Render(details[0])
+		}
+	}
+
+	label := strings.Join(markedNames(node), ", ")
+	if len(details) > 0 {
+		label = fmt.Sprintf("%s (%s)", label, strings.Join(details, ", "))
+	}
+	if len(node.AlsoFrom) > 0 {
+		label += fmt.Sprintf(" (also from %s)", strings.Join(node.AlsoFrom, ", "))
+	}
+	if node.Merged {
+		label += " [merged]"
+	} else if node.SquashMerged {
+		label += " [squash-merged]"
+	}
+	return label
+}
+
+// returns the name and aliases of a node, with an asterisk after the one
+// that is checked out
+func markedNames(node *Node) []string {
+	current := node.CheckedOut()
+	names := append([]string{node.Name}, node.Aliases...)
+	for i, name := range names {
+		if i == 0 {
+			names[i] = displayName(node)
+		}
+		if name == current {
+			names[i] += "*"
+		}
+	}
+	return names
+}
+
+// returns the name a node is shown by; a detached HEAD has no branch name
+// and is shown by the commit it points at
+func displayName(node *Node) string {
+	if node.IsDetached {
+		return fmt.Sprintf("HEAD (detached at %s)", node.ShortHash())
+	}
+	return node.Name
+}
+
+// returns the parenthesized parts of a label: age, commit counts and whether
+// the branch has diverged from its parent
+func labelDetails(node *Node) []string {
+	var details []string
 	if !node.LastCommit.IsZero() {
-		displayName = fmt.Sprintf("%s (%s ago)", node.Name, timefmt.RelativeTime(node.LastCommit))
+		details = append(details, timefmt.RelativeTime(node.LastCommit)+" ago")
+	}
+
+	var counts []string
+	if node.Ahead > 0 {
+		counts = append(counts, fmt.Sprintf("+%d", node.Ahead))
 	}
-	return displayName
+	if node.Behind > 0 {
+		counts = append(counts, fmt.Sprintf("-%d", node.Behind))
+	}
+	if len(counts) > 0 {
+		details = append(details, strings.Join(counts, " "))
+	}
+	// the parent has moved on since the branch was cut from it
+	if node.Behind > 0 {
+		details = append(details, "diverged")
+	}
+	return details
 }
 
 func (p *Printer) printNode(node *Node, prefix string, isLast bool) {
 	displayName := p.formatName(node)
 
 	if prefix != "" {
-		connector := "├── "
-		if isLast {
-			connector = "└── "
-		}
-		fmt.Fprintf(p.output, "%s%s%s\n", prefix, connector, displayName)
+		fmt.Fprintf(p.output, "%s%s%s\n", prefix, connector(node, isLast), displayName)
 	} else {
 		fmt.Fprintf(p.output, "%s\n", displayName)
 	}
@@ -60,11 +197,7 @@ func (p *Printer) printNode(node *Node, prefix string, isLast bool) {
 		childIsLast := i == len(node.Children)-1
 
 		if prefix == "" {
-			connector := "├── "
-			if childIsLast {
-				connector = "└── "
-			}
-			fmt.Fprintf(p.output, "%s%s\n", connector, p.formatName(child))
+			fmt.Fprintf(p.output, "%s%s\n", connector(child, childIsLast), p.formatName(child))
 			for j, grandchild := range child.Children {
 				grandchildIsLast := j == len(child.Children)-1
 				grandchildPrefix := "    "
@@ -2,10 +2,12 @@ package tree
 
 import (
 	"bytes"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestPrinter_Print(t *testing.T) {
@@ -56,6 +58,18 @@ func TestPrinter_Print(t *testing.T) {
 			},
 			want: "master\n└── develop\n    └── feature\n",
 		},
+		{
+			name: "detached HEAD",
+			tree: &Tree{
+				Root: &Node{
+					Name: "master",
+					Children: []*Node{
+						{Name: "HEAD", IsDetached: true, IsCurrent: true, Hash: "abc1234def5678", Children: []*Node{}},
+					},
+				},
+			},
+			want: "master\n└── HEAD (detached at abc1234)*\n",
+		},
 		{
 			name: "complex tree",
 			tree: &Tree{
@@ -66,7 +80,7 @@ func TestPrinter_Print(t *testing.T) {
 						{
 							Name: "feat/feature-1",
 							Children: []*Node{
-								{Name: "chore/document-change*", Children: []*Node{}, LastCommit: time.Now().Add(-30 * time.Hour)},
+								{Name: "chore/document-change", IsCurrent: true, Children: []*Node{}, LastCommit: time.Now().Add(-30 * time.Hour)},
 							},
 							LastCommit: time.Now().Add(-28 * time.Hour),
 						},
@@ -76,6 +90,90 @@ func TestPrinter_Print(t *testing.T) {
 			},
 			want: "main\n├── fix/important-bug (5h ago)\n├── feat/feature-1 (1d ago)\n│   └── chore/document-change* (1d ago)\n└── chore/no-commits-yet (2d ago)\n",
 		},
+		{
+			name: "ahead and behind counts",
+			tree: &Tree{
+				Root: &Node{
+					Name: "main",
+					Children: []*Node{
+						{Name: "feat/x", Children: []*Node{}, LastCommit: time.Now().Add(-3 * time.Hour), Ahead: 4},
+						{Name: "feat/y", Children: []*Node{}, Ahead: 1, Behind: 2},
+					},
+				},
+			},
+			want: "main\n├── feat/x (3h ago, +4)\n└── feat/y (+1 -2, diverged)\n",
+		},
+		{
+			name: "merged branch",
+			tree: &Tree{
+				Root: &Node{
+					Name: "main",
+					Children: []*Node{
+						{Name: "feat/done", Children: []*Node{}, LastCommit: time.Now().Add(-3 * time.Hour), Merged: true},
+						{Name: "feat/wip", Children: []*Node{}},
+					},
+				},
+			},
+			want: "main\n├── feat/done (3h ago) [merged]\n└── feat/wip\n",
+		},
+		{
+			name: "squash-merged branch",
+			tree: &Tree{
+				Root: &Node{
+					Name: "main",
+					Children: []*Node{
+						{Name: "feat/squashed", Children: []*Node{}, Ahead: 2, Behind: 1, SquashMerged: true},
+					},
+				},
+			},
+			want: "main\n└── feat/squashed (+2 -1, diverged) [squash-merged]\n",
+		},
+		{
+			name: "recorded parents",
+			tree: &Tree{
+				Root: &Node{
+					Name: "main",
+					Children: []*Node{
+						{Name: "feat/pinned", Recorded: true, Children: []*Node{
+							{Name: "feat/child", Recorded: true, Children: []*Node{}},
+						}},
+						{Name: "feat/inferred", Children: []*Node{}},
+					},
+				},
+			},
+			want: "main\n├══ feat/pinned\n│   └══ feat/child\n└── feat/inferred\n",
+		},
+		{
+			name: "branches at the same commit",
+			tree: &Tree{
+				Root: &Node{
+					Name:         "main",
+					Aliases:      []string{"feat/no-commit-branch"},
+					IsCurrent:    true,
+					CurrentAlias: "feat/no-commit-branch",
+					Children: []*Node{
+						{Name: "feat/x", Aliases: []string{"feat/y"}, Children: []*Node{}, Ahead: 1},
+					},
+				},
+			},
+			want: "main, feat/no-commit-branch*\n└── feat/x, feat/y (+1)\n",
+		},
+		{
+			name: "branch with several parents",
+			tree: &Tree{
+				Root: &Node{
+					Name: "main",
+					Children: []*Node{
+						{Name: "feat/a", Children: []*Node{
+							{Name: "feat/merge", AlsoFrom: []string{"feat/b", "feat/c"}, Children: []*Node{}, Ahead: 1},
+						}},
+						{Name: "feat/b", Children: []*Node{}},
+						{Name: "feat/c", Children: []*Node{}},
+					},
+				},
+			},
+			want: "main\n├── feat/a\n│   └── feat/merge (+1) (also from feat/b, feat/c)\n├── feat/b\n└── feat/c\n",
+		},
 		{
 			name: "nil tree",
 			tree: nil,
@@ -132,3 +230,73 @@ func TestPrinter_PrintNode(t *testing.T) {
 	assert.Contains(t, output, "├── child1")
 	assert.Contains(t, output, "└── child2")
 }
+
+func TestPrinter_SetFormat(t *testing.T) {
+	format, err := ParseFormat("{{.Name}} {{.ShortHash}}")
+	require.NoError(t, err)
+
+	tree := &Tree{Root: &Node{Name: rootNodeName, Children: []*Node{
+		{Name: "main", Hash: "0123456789", Children: []*Node{
+			{Name: "feature", Hash: "abcdef0123"},
+		}},
+	}}}
+
+	var buf bytes.Buffer
+	printer := NewPrinter(&buf)
+	printer.SetFormat(format)
+	printer.Print(tree)
+
+	assert.Equal(t, ".\n└── main 0123456\n    └── feature abcdef0\n", buf.String())
+}
+
+func TestPrinter_SetVerbose(t *testing.T) {
+	tree := &Tree{Root: &Node{Name: rootNodeName, Children: []*Node{
+		{Name: "main", Hash: "0123456789", Author: "Jane", AuthorEmail: "jane@example.com", Subject: "Release", Children: []*Node{
+			{Name: "feature", Hash: "abcdef0123", Author: "John Smith", AuthorEmail: "john@example.com", Subject: "Add form"},
+		}},
+	}}}
+
+	var buf bytes.Buffer
+	printer := NewPrinter(&buf)
+	printer.SetVerbose(true)
+	printer.Print(tree)
+
+	want := ".\n" +
+		"└── main         0123456  Jane <jane@example.com>        Release\n" +
+		"    └── feature  abcdef0  John Smith <john@example.com>  Add form\n"
+	assert.Equal(t, want, buf.String())
+}
+
+func TestPrinter_SetColor(t *testing.T) {
+	newTree := func() *Tree {
+		return &Tree{Root: &Node{Name: rootNodeName, Children: []*Node{
+			{Name: "main", LastCommit: time.Now().Add(-2 * time.Hour), Children: []*Node{
+				{Name: "feature", IsCurrent: true},
+				{Name: "done", Merged: true},
+			}},
+		}}}
+	}
+
+	var plain bytes.Buffer
+	printer := NewPrinter(&plain)
+	require.NoError(t, printer.SetColor(ColorNever))
+	printer.Print(newTree())
+	assert.NotContains(t, plain.String(), "\x1b[")
+
+	var colored bytes.Buffer
+	printer = NewPrinter(&colored)
+	require.NoError(t, printer.SetColor(ColorAlways))
+	printer.Print(newTree())
+
+	lines := strings.Split(colored.String(), "\n")
+	require.Len(t, lines, 5)
+	assert.Equal(t, ".", lines[0])
+	assert.Contains(t, lines[1], "└── main (\x1b[")
+	assert.Contains(t, lines[2], "\x1b[")
+	assert.Contains(t, lines[2], "feature*")
+	assert.Contains(t, lines[3], "\x1b[")
+	assert.Contains(t, lines[3], "done [merged]")
+	assert.NotEqual(t, plain.String(), colored.String())
+
+	assert.ErrorIs(t, NewPrinter(&colored).SetColor("sometimes"), ErrUnknownColorMode)
+}
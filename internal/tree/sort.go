@@ -0,0 +1,91 @@
+package tree
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+var (
+	ErrUnknownSortOrder = errors.New("unknown sort order")
+)
+
+// SortOrder selects how the children of a node are ordered.
+type SortOrder string
+
+const (
+	// alphabetical by branch name
+	SortName SortOrder = "name"
+	// most recent last commit first
+	SortDate SortOrder = "date"
+	// oldest branch first, by the time it was created
+	SortCreation SortOrder = "creation"
+	// most commits ahead of the parent first
+	SortAhead SortOrder = "ahead"
+)
+
+// Sort orders the children of every node of t. Ties, and branches whose
+// creation time is unknown, fall back to name order.
+func Sort(t *Tree, order SortOrder) error {
+	less, err := lessFunc(order)
+	if err != nil {
+		return err
+	}
+	sortTree(t, less)
+	return nil
+}
+
+// Validate returns ErrUnknownSortOrder unless o is one of the sort orders.
+func (o SortOrder) Validate() error {
+	_, err := lessFunc(o)
+	return err
+}
+
+func sortTree(t *Tree, less func(x, y *Node) bool) {
+	t.Walk(func(node *Node) {
+		sort.SliceStable(node.Children, func(i, j int) bool {
+			return less(node.Children[i], node.Children[j])
+		})
+	})
+}
+
+func lessFunc(order SortOrder) (func(x, y *Node) bool, error) {
+	switch order {
+	case "", SortName:
+		return byName, nil
+
+	case SortDate:
+		return func(x, y *Node) bool {
+			if !x.LastCommit.Equal(y.LastCommit) {
+				return x.LastCommit.After(y.LastCommit)
+			}
+			return byName(x, y)
+		}, nil
+
+	case SortCreation:
+		return func(x, y *Node) bool {
+			if x.Created.IsZero() != y.Created.IsZero() {
+				return !x.Created.IsZero()
+			}
+			if !x.Created.Equal(y.Created) {
+				return x.Created.Before(y.Created)
+			}
+			return byName(x, y)
+		}, nil
+
+	case SortAhead:
+		return func(x, y *Node) bool {
+			if x.Ahead != y.Ahead {
+				return x.Ahead > y.Ahead
+			}
+			return byName(x, y)
+		}, nil
+
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownSortOrder, order)
+	}
+}
+
+func byName(x, y *Node) bool {
+	return x.Name < y.Name
+}
@@ -0,0 +1,57 @@
+package tree
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSort(t *testing.T) {
+	now := time.Now()
+
+	newTree := func() *Tree {
+		return &Tree{
+			Root: &Node{
+				Name: "main",
+				Children: []*Node{
+					{Name: "feat/b", LastCommit: now.Add(-2 * time.Hour), Created: now.Add(-3 * time.Hour), Ahead: 1},
+					{Name: "feat/c", IsCurrent: true, LastCommit: now, Ahead: 5},
+					{Name: "feat/a", LastCommit: now.Add(-1 * time.Hour), Created: now.Add(-5 * time.Hour), Ahead: 1},
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		order SortOrder
+		want  []string
+	}{
+		{"", []string{"feat/a", "feat/b", "feat/c"}},
+		{SortName, []string{"feat/a", "feat/b", "feat/c"}},
+		{SortDate, []string{"feat/c", "feat/a", "feat/b"}},
+		{SortCreation, []string{"feat/a", "feat/b", "feat/c"}},
+		{SortAhead, []string{"feat/c", "feat/a", "feat/b"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.order), func(t *testing.T) {
+			tree := newTree()
+			require.NoError(t, Sort(tree, tt.order))
+
+			var got []string
+			for _, child := range tree.Root.Children {
+				got = append(got, child.Name)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestSort_UnknownOrder(t *testing.T) {
+	err := Sort(&Tree{Root: &Node{Name: "main"}}, "size")
+	assert.ErrorIs(t, err, ErrUnknownSortOrder)
+	assert.ErrorIs(t, SortOrder("size").Validate(), ErrUnknownSortOrder)
+	assert.NoError(t, SortOrder("").Validate())
+}
@@ -0,0 +1,66 @@
+package tree
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+const columnGap = "  "
+
+// returns the short hash, commit date, author as "Name <email>" and subject
+// of a node, or nil for nodes without a commit such as the root
+func verboseColumns(node *Node) []string {
+	if node.Hash == "" {
+		return nil
+	}
+	date := ""
+	if !node.LastCommit.IsZero() {
+		date = node.LastCommit.Format("2006-01-02 15:04")
+	}
+	author := node.Author
+	if node.AuthorEmail != "" {
+		author = fmt.Sprintf("%s <%s>", node.Author, node.AuthorEmail)
+	}
+	return []string{node.ShortHash(), date, author, node.Subject}
+}
+
+// AlignColumns appends the columns of every item to its text, padding the
+// text and every column but the last to a common width, like
+// `git branch -vv`. Columns empty on every line are left out.
+func AlignColumns(items []Item) []string {
+	var widths []int
+	for _, item := range items {
+		cells := append([]string{item.Text}, item.Columns...)
+		for i, cell := range cells[:len(cells)-1] {
+			if i == len(widths) {
+				widths = append(widths, 0)
+			}
+			widths[i] = max(widths[i], utf8.RuneCountInString(cell))
+		}
+	}
+
+	lines := make([]string, len(items))
+	for i, item := range items {
+		if len(item.Columns) == 0 {
+			lines[i] = item.Text
+			continue
+		}
+
+		var b strings.Builder
+		cells := append([]string{item.Text}, item.Columns...)
+		for j, cell := range cells {
+			// a column empty on every line takes no room
+			if j < len(cells)-1 && widths[j] == 0 {
+				continue
+			}
+			b.WriteString(cell)
+			if j < len(cells)-1 {
+				b.WriteString(strings.Repeat(" ", widths[j]-utf8.RuneCountInString(cell)))
+				b.WriteString(columnGap)
+			}
+		}
+		lines[i] = strings.TrimRight(b.String(), " ")
+	}
+	return lines
+}
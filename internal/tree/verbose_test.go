@@ -0,0 +1,68 @@
+package tree
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAlignColumns(t *testing.T) {
+	tests := []struct {
+		name  string
+		items []Item
+		want  []string
+	}{
+		{
+			name:  "no items",
+			items: nil,
+			want:  []string{},
+		},
+		{
+			name: "rows without columns are left alone",
+			items: []Item{
+				{Text: "."},
+				{Text: "└── main", Columns: []string{"0123456", "Jane", "Release"}},
+				{Text: "    └── feature", Columns: []string{"abcdef0", "John Smith", "Add form"}},
+			},
+			want: []string{
+				".",
+				"└── main         0123456  Jane        Release",
+				"    └── feature  abcdef0  John Smith  Add form",
+			},
+		},
+		{
+			name: "empty last column leaves no trailing space",
+			items: []Item{
+				{Text: "main", Columns: []string{"0123456", ""}},
+				{Text: "feature", Columns: []string{"abcdef0", "Add form"}},
+			},
+			want: []string{
+				"main     0123456",
+				"feature  abcdef0  Add form",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, AlignColumns(tt.items))
+		})
+	}
+}
+
+func TestVerboseColumns(t *testing.T) {
+	node := &Node{
+		Name:        "feature",
+		Hash:        "abcdef0123456789",
+		Subject:     "Add form",
+		Author:      "Jane",
+		AuthorEmail: "jane@example.com",
+		LastCommit:  time.Date(2024, 5, 1, 9, 30, 0, 0, time.UTC),
+	}
+	assert.Equal(t, []string{"abcdef0", "2024-05-01 09:30", "Jane <jane@example.com>", "Add form"}, verboseColumns(node))
+
+	node.AuthorEmail = ""
+	assert.Equal(t, "Jane", verboseColumns(node)[2])
+	assert.Nil(t, verboseColumns(&Node{Name: rootNodeName}))
+}
@@ -2,7 +2,6 @@ package tui
 
 import (
 	"fmt"
-	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
@@ -10,20 +9,55 @@ import (
 	"github.com/mucansever/gittree/internal/tree"
 )
 
+// lines around the items that are always drawn: the two lines of help, the
+// blank line below them and the empty line after the final newline
+const chromeHeight = 4
+
 type Model struct {
+	tree   *tree.Tree
+	format *tree.Format
+	// branches whose children are hidden
+	folded map[string]bool
+	// lines of the tree as currently folded
 	items    []tree.Item
 	cursor   int
 	repo     *git.Repository
 	err      error
 	quitting bool
 	message  string
+	// branches sharing the selected node, while one of them is being picked
+	aliases     []string
+	aliasCursor int
+	// the one of aliases that is checked out, if any
+	aliasCurrent string
+	// items with their commit details in aligned columns, shown in verbose mode
+	verboseLines []string
+	verbose      bool
+	// terminal size, zero until the first WindowSizeMsg
+	width  int
+	height int
+	// index of the first item on screen
+	offset int
 }
 
-func NewModel(items []tree.Item, repo *git.Repository) Model {
-	return Model{
-		items: items,
-		repo:  repo,
+// NewModel shows t with every branch unfolded, labelling branches with
+// format, or the default label when it is nil.
+func NewModel(t *tree.Tree, format *tree.Format, repo *git.Repository) Model {
+	m := Model{
+		tree:   t,
+		format: format,
+		folded: make(map[string]bool),
+		repo:   repo,
 	}
+	m.refresh()
+	return m
+}
+
+// lays out the items again after branches were folded or unfolded
+func (m *Model) refresh() {
+	m.items = tree.FlattenFolded(m.tree, m.format, m.folded)
+	m.verboseLines = tree.AlignColumns(m.items)
+	m.cursor = max(min(m.cursor, len(m.items)-1), 0)
 }
 
 func (m Model) Init() tea.Cmd {
@@ -31,67 +65,320 @@ func (m Model) Init() tea.Cmd {
 }
 
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
 	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
 	case tea.KeyMsg:
-		switch msg.String() {
-		case "ctrl+c", "q", "esc":
-			m.quitting = true
-			return m, tea.Quit
-		case "up", "k":
-			if m.cursor > 0 {
-				m.cursor--
-			}
-		case "down", "j":
-			if m.cursor < len(m.items)-1 {
-				m.cursor++
-			}
-		case "enter":
-			selected := m.items[m.cursor]
-			// skip checkout if it's the root
-			if selected.BranchName == "." {
-				m.message = "Cannot checkout root node."
-				return m, nil
-			}
+		if m.aliases != nil {
+			m, cmd = m.updateAliasPick(msg)
+		} else {
+			m, cmd = m.updateKey(msg)
+		}
+	}
+	// the picker and messages take room from the list too
+	m.scrollToCursor()
+	return m, cmd
+}
 
-			branchName := strings.TrimSuffix(selected.BranchName, "*")
-			err := m.repo.Checkout(branchName)
-			if err != nil {
-				m.err = err
-				m.message = fmt.Sprintf("Error checking out %s: %v", branchName, err)
-			} else {
-				m.message = fmt.Sprintf("Checked out %s", branchName)
-				m.quitting = true
-				return m, tea.Quit
+// handles keys while browsing the tree
+func (m Model) updateKey(msg tea.KeyMsg) (Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c", "q", "esc":
+		m.quitting = true
+		return m, tea.Quit
+	case "up", "k":
+		if m.cursor > 0 {
+			m.cursor--
+		}
+	case "down", "j":
+		if m.cursor < len(m.items)-1 {
+			m.cursor++
+		}
+	case "pgup":
+		m.cursor = max(m.cursor-m.listHeight(), 0)
+	case "pgdown":
+		m.cursor = max(min(m.cursor+m.listHeight(), len(m.items)-1), 0)
+	case "home", "g":
+		m.cursor = 0
+	case "end", "G":
+		m.cursor = max(len(m.items)-1, 0)
+	case "left", "h":
+		if m.canFold(m.cursor) && !m.folded[m.items[m.cursor].BranchName] {
+			m.setFolded(m.items[m.cursor].BranchName, true)
+		} else {
+			m.cursor = m.parentIndex(m.cursor)
+		}
+	case "right", "l":
+		if m.canFold(m.cursor) && m.folded[m.items[m.cursor].BranchName] {
+			m.setFolded(m.items[m.cursor].BranchName, false)
+		}
+	case " ":
+		if m.canFold(m.cursor) {
+			name := m.items[m.cursor].BranchName
+			m.setFolded(name, !m.folded[name])
+		}
+	case "c":
+		m.collapseAll()
+	case "e":
+		m.expandToCurrent()
+	case "v":
+		m.verbose = !m.verbose
+	case "enter":
+		selected := m.items[m.cursor]
+		// skip checkout if it's the root
+		if selected.IsRoot() {
+			m.message = "Cannot checkout root node."
+			return m, nil
+		}
+
+		if selected.IsDetached {
+			m.message = "Already at the detached HEAD, pick a branch to checkout."
+			return m, nil
+		}
+
+		if len(selected.Aliases) > 0 {
+			m.aliases = append([]string{selected.BranchName}, selected.Aliases...)
+			m.aliasCursor = 0
+			m.aliasCurrent = ""
+			if selected.IsCurrent {
+				m.aliasCurrent = selected.BranchName
+				if selected.CurrentAlias != "" {
+					m.aliasCurrent = selected.CurrentAlias
+				}
 			}
+			m.message = ""
+			return m, nil
+		}
+
+		return m.checkout(selected.BranchName)
+	}
+	return m, nil
+}
+
+// reports whether the item at i has children that can be hidden; the root
+// always stays open
+func (m Model) canFold(i int) bool {
+	return i < len(m.items) && m.items[i].Children > 0 && !m.items[i].IsRoot()
+}
+
+// folds or unfolds a branch; the items above it keep their place, so the
+// cursor stays on it
+func (m *Model) setFolded(name string, folded bool) {
+	if folded {
+		m.folded[name] = true
+	} else {
+		delete(m.folded, name)
+	}
+	m.refresh()
+}
+
+// returns the index of the parent of the item at i, or i for the root
+func (m Model) parentIndex(i int) int {
+	for j := i - 1; j >= 0; j-- {
+		if m.items[j].Depth < m.items[i].Depth {
+			return j
+		}
+	}
+	return i
+}
+
+// folds every branch with children, leaving the top-level branches in view
+// and the cursor on the one it was under
+func (m *Model) collapseAll() {
+	if len(m.items) == 0 {
+		return
+	}
+	top := m.cursor
+	for m.items[top].Depth > 1 {
+		top = m.parentIndex(top)
+	}
+	name, depth := m.items[top].BranchName, m.items[top].Depth
+
+	m.tree.Walk(func(node *tree.Node) {
+		if len(node.Children) > 0 && node != m.tree.Root {
+			m.folded[node.Name] = true
+		}
+	})
+	m.refresh()
+	m.moveTo(func(item tree.Item) bool {
+		return item.BranchName == name && item.Depth == depth
+	})
+}
+
+// unfolds the branches above the checked out one and moves the cursor to it
+func (m *Model) expandToCurrent() {
+	if m.tree == nil || m.tree.Root == nil {
+		return
+	}
+	for _, node := range pathToCurrent(m.tree.Root) {
+		delete(m.folded, node.Name)
+	}
+	m.refresh()
+	m.moveTo(func(item tree.Item) bool { return item.IsCurrent })
+}
+
+// puts the cursor on the first item matching, if any
+func (m *Model) moveTo(match func(item tree.Item) bool) {
+	for i, item := range m.items {
+		if match(item) {
+			m.cursor = i
+			return
 		}
 	}
+}
+
+// returns the ancestors of the checked out branch, from the root down, or
+// nil when it is not in the tree
+func pathToCurrent(node *tree.Node) []*tree.Node {
+	if node.IsCurrent {
+		return []*tree.Node{}
+	}
+	for _, child := range node.Children {
+		if path := pathToCurrent(child); path != nil {
+			return append([]*tree.Node{node}, path...)
+		}
+	}
+	return nil
+}
+
+// handles keys while choosing which of several branches at the same commit
+// to checkout
+func (m Model) updateAliasPick(msg tea.KeyMsg) (Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c", "q":
+		m.quitting = true
+		return m, tea.Quit
+	case "esc":
+		m.aliases = nil
+	case "up", "k":
+		if m.aliasCursor > 0 {
+			m.aliasCursor--
+		}
+	case "down", "j":
+		if m.aliasCursor < len(m.aliases)-1 {
+			m.aliasCursor++
+		}
+	case "enter":
+		name := m.aliases[m.aliasCursor]
+		m.aliases = nil
+		return m.checkout(name)
+	}
 	return m, nil
 }
 
+func (m Model) checkout(name string) (Model, tea.Cmd) {
+	err := m.repo.Checkout(name)
+	if err != nil {
+		m.err = err
+		m.message = fmt.Sprintf("Error checking out %s: %v", name, err)
+		return m, nil
+	}
+
+	m.message = fmt.Sprintf("Checked out %s", name)
+	m.quitting = true
+	return m, tea.Quit
+}
+
+// returns how many items fit on screen between the title and the alias
+// picker or message, or all of them until the terminal size is known
+func (m Model) listHeight() int {
+	if m.height == 0 {
+		return len(m.items)
+	}
+
+	footer := 0
+	if m.aliases != nil {
+		// blank line, question and one line per branch
+		footer += 2 + len(m.aliases)
+	}
+	if m.message != "" {
+		footer += 2
+	}
+	return max(m.height-chromeHeight-footer, 1)
+}
+
+// moves the viewport just enough to show the cursor, without leaving empty
+// rows below the last item
+func (m *Model) scrollToCursor() {
+	height := m.listHeight()
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	}
+	if m.cursor >= m.offset+height {
+		m.offset = m.cursor - height + 1
+	}
+	m.offset = max(min(m.offset, len(m.items)-height), 0)
+}
+
+// cuts s to the terminal width, once it is known
+func (m Model) truncate(s string, width int) string {
+	if m.width == 0 {
+		return s
+	}
+	return lipgloss.NewStyle().MaxWidth(max(width, 0)).Render(s)
+}
+
 func (m Model) View() string {
 	if m.quitting {
 		return fmt.Sprintf("%s\n", m.message)
 	}
 
-	s := lipgloss.NewStyle().Bold(true).Render("Navigate branches (Up/Down), Enter to checkout, q to quit.") + "\n\n"
+	help := []string{
+		"Navigate branches (Up/Down, PgUp/PgDn, g/G), Enter to checkout, v for details, q to quit.",
+		"Fold with Left/Right or Space, c to collapse all, e to expand to the current branch.",
+	}
+	s := ""
+	for _, line := range help {
+		s += lipgloss.NewStyle().Bold(true).Render(m.truncate(line, m.width)) + "\n"
+	}
+	s += "\n"
 
-	for i, item := range m.items {
+	end := min(m.offset+m.listHeight(), len(m.items))
+	for i := m.offset; i < end; i++ {
+		item := m.items[i]
 		cursor := "  "
 		if m.cursor == i {
 			cursor = "> "
 		}
 
 		line := item.Text
+		if m.verbose {
+			line = m.verboseLines[i]
+		}
+		line = m.truncate(line, m.width-len(cursor))
 		if m.cursor == i {
 			line = lipgloss.NewStyle().Foreground(lipgloss.Color("205")).Bold(true).Render(line)
 			cursor = lipgloss.NewStyle().Foreground(lipgloss.Color("205")).Render(cursor)
+		} else if item.Merged || item.SquashMerged {
+			line = lipgloss.NewStyle().Faint(true).Render(line)
+		} else if item.IsCurrent {
+			line = lipgloss.NewStyle().Foreground(lipgloss.Color("42")).Render(line)
 		}
 
 		s += fmt.Sprintf("%s%s\n", cursor, line)
 	}
 
+	if m.aliases != nil {
+		question := m.truncate("Several branches point here, pick one (Up/Down, Enter, Esc to go back):", m.width)
+		s += "\n" + lipgloss.NewStyle().Bold(true).Render(question) + "\n"
+		for i, name := range m.aliases {
+			cursor := "  "
+			line := name
+			if name == m.aliasCurrent {
+				line += "*"
+			}
+			line = m.truncate(line, m.width-len(cursor))
+			if m.aliasCursor == i {
+				cursor = lipgloss.NewStyle().Foreground(lipgloss.Color("205")).Render("> ")
+				line = lipgloss.NewStyle().Foreground(lipgloss.Color("205")).Bold(true).Render(line)
+			}
+			s += fmt.Sprintf("%s%s\n", cursor, line)
+		}
+	}
+
 	if m.message != "" {
-		s += "\n" + lipgloss.NewStyle().Foreground(lipgloss.Color("196")).Render(m.message) + "\n"
+		s += "\n" + lipgloss.NewStyle().Foreground(lipgloss.Color("196")).Render(m.truncate(m.message, m.width)) + "\n"
 	}
 
 	return s
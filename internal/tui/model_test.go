@@ -0,0 +1,296 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mucansever/gittree/internal/tree"
+)
+
+// returns a model of a root with n-1 branches below it, one per line
+func newTestModel(n int) Model {
+	root := tree.NewNode(".", time.Time{})
+	for i := 1; i < n; i++ {
+		root.AddChild(tree.NewNode(fmt.Sprintf("branch-%02d", i), time.Time{}))
+	}
+	return NewModel(&tree.Tree{Root: root}, nil, nil)
+}
+
+func update(t *testing.T, m Model, msgs ...tea.Msg) Model {
+	t.Helper()
+	for _, msg := range msgs {
+		next, _ := m.Update(msg)
+		var ok bool
+		m, ok = next.(Model)
+		require.True(t, ok)
+	}
+	return m
+}
+
+func key(s string) tea.KeyMsg {
+	switch s {
+	case "pgup":
+		return tea.KeyMsg{Type: tea.KeyPgUp}
+	case "pgdown":
+		return tea.KeyMsg{Type: tea.KeyPgDown}
+	case "home":
+		return tea.KeyMsg{Type: tea.KeyHome}
+	case "end":
+		return tea.KeyMsg{Type: tea.KeyEnd}
+	case "down":
+		return tea.KeyMsg{Type: tea.KeyDown}
+	case "up":
+		return tea.KeyMsg{Type: tea.KeyUp}
+	case "left":
+		return tea.KeyMsg{Type: tea.KeyLeft}
+	case "right":
+		return tea.KeyMsg{Type: tea.KeyRight}
+	case " ":
+		return tea.KeyMsg{Type: tea.KeySpace, Runes: []rune(" ")}
+	}
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)}
+}
+
+// returns the lines of the view, without styling
+func viewLines(m Model) []string {
+	var lines []string
+	for _, line := range strings.Split(m.View(), "\n") {
+		lines = append(lines, strings.TrimRight(stripANSI(line), " "))
+	}
+	return lines
+}
+
+func stripANSI(s string) string {
+	var b strings.Builder
+	inEscape := false
+	for _, r := range s {
+		switch {
+		case r == '\x1b':
+			inEscape = true
+		case inEscape:
+			inEscape = r != 'm'
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func TestModel_Viewport(t *testing.T) {
+	m := update(t, newTestModel(50), tea.WindowSizeMsg{Width: 80, Height: 14})
+	// 14 lines less the help, the blank line and the trailing empty line
+	assert.Equal(t, 10, m.listHeight())
+
+	lines := viewLines(m)
+	assert.Len(t, lines, 14)
+	assert.Equal(t, "> .", lines[3])
+	assert.Equal(t, "  ├── branch-09", lines[12])
+
+	tests := []struct {
+		keys       []string
+		wantCursor int
+		wantOffset int
+	}{
+		{keys: []string{"down"}, wantCursor: 1, wantOffset: 0},
+		{keys: []string{"pgdown"}, wantCursor: 10, wantOffset: 1},
+		{keys: []string{"pgdown", "pgdown", "pgup"}, wantCursor: 10, wantOffset: 10},
+		{keys: []string{"end"}, wantCursor: 49, wantOffset: 40},
+		{keys: []string{"G"}, wantCursor: 49, wantOffset: 40},
+		{keys: []string{"G", "pgdown"}, wantCursor: 49, wantOffset: 40},
+		{keys: []string{"G", "home"}, wantCursor: 0, wantOffset: 0},
+		{keys: []string{"G", "g"}, wantCursor: 0, wantOffset: 0},
+		{keys: []string{"G", "up"}, wantCursor: 48, wantOffset: 40},
+		{keys: []string{"pgup"}, wantCursor: 0, wantOffset: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.keys, ","), func(t *testing.T) {
+			got := m
+			for _, k := range tt.keys {
+				got = update(t, got, key(k))
+			}
+			assert.Equal(t, tt.wantCursor, got.cursor)
+			assert.Equal(t, tt.wantOffset, got.offset)
+
+			lines := viewLines(got)
+			assert.Len(t, lines, 14)
+			assert.Contains(t, lines, "> "+got.items[got.cursor].Text)
+		})
+	}
+}
+
+func TestModel_Resize(t *testing.T) {
+	m := update(t, newTestModel(50), tea.WindowSizeMsg{Width: 80, Height: 14}, key("G"))
+	assert.Equal(t, 40, m.offset)
+
+	// growing shows more items above rather than empty rows below
+	m = update(t, m, tea.WindowSizeMsg{Width: 80, Height: 24})
+	assert.Equal(t, 30, m.offset)
+	assert.Len(t, viewLines(m), 24)
+
+	// shrinking keeps the cursor on screen
+	m = update(t, m, tea.WindowSizeMsg{Width: 80, Height: 9})
+	assert.Equal(t, 45, m.offset)
+	assert.Contains(t, viewLines(m), "> └── branch-49")
+}
+
+func TestModel_Truncate(t *testing.T) {
+	m := update(t, newTestModel(3), tea.WindowSizeMsg{Width: 10, Height: 20})
+
+	for _, line := range strings.Split(m.View(), "\n") {
+		assert.LessOrEqual(t, lipgloss.Width(line), 10, line)
+	}
+	assert.Contains(t, viewLines(m), "  ├── bran")
+}
+
+func TestModel_NoWindowSize(t *testing.T) {
+	m := newTestModel(30)
+	assert.Equal(t, 30, m.listHeight())
+	assert.Len(t, viewLines(m), 30+chromeHeight)
+}
+
+// returns a model of the tree
+//
+//	.
+//	└── main
+//	    ├── feat-a
+//	    │   └── feat-a2
+//	    │       └── feat-a3
+//	    └── feat-b*
+//	        └── feat-b2
+func newStackModel() Model {
+	node := func(name string, children ...*tree.Node) *tree.Node {
+		n := tree.NewNode(name, time.Time{})
+		for _, child := range children {
+			n.AddChild(child)
+		}
+		return n
+	}
+	featB := node("feat-b", node("feat-b2"))
+	featB.IsCurrent = true
+	root := node(".", node("main",
+		node("feat-a", node("feat-a2", node("feat-a3"))),
+		featB,
+	))
+	return NewModel(&tree.Tree{Root: root}, nil, nil)
+}
+
+func itemTexts(m Model) []string {
+	var texts []string
+	for _, item := range m.items {
+		texts = append(texts, item.Text)
+	}
+	return texts
+}
+
+func TestModel_Fold(t *testing.T) {
+	tests := []struct {
+		name       string
+		keys       []string
+		wantCursor int
+		wantTexts  []string
+	}{
+		{
+			name:       "left folds a branch",
+			keys:       []string{"down", "down", "left"},
+			wantCursor: 2,
+			wantTexts: []string{
+				".",
+				"└── main",
+				"    ├── feat-a [+2]",
+				"    └── feat-b*",
+				"        └── feat-b2",
+			},
+		},
+		{
+			name:       "left on a folded branch moves to its parent",
+			keys:       []string{"down", "down", "h", "h"},
+			wantCursor: 1,
+		},
+		{
+			name:       "left on a leaf moves to its parent",
+			keys:       []string{"down", "down", "down", "down", "left"},
+			wantCursor: 3,
+		},
+		{
+			name:       "right unfolds",
+			keys:       []string{"down", "down", "left", "l"},
+			wantCursor: 2,
+			wantTexts: []string{
+				".",
+				"└── main",
+				"    ├── feat-a",
+				"    │   └── feat-a2",
+				"    │       └── feat-a3",
+				"    └── feat-b*",
+				"        └── feat-b2",
+			},
+		},
+		{
+			name:       "space toggles",
+			keys:       []string{"down", " "},
+			wantCursor: 1,
+			wantTexts:  []string{".", "└── main [+5]"},
+		},
+		{
+			name:       "root stays open",
+			keys:       []string{" ", "left", "right"},
+			wantCursor: 0,
+		},
+		{
+			name:       "collapse all keeps the top-level branch under the cursor",
+			keys:       []string{"G", "c"},
+			wantCursor: 1,
+			wantTexts:  []string{".", "└── main [+5]"},
+		},
+		{
+			name:       "expand to the current branch",
+			keys:       []string{"c", "e"},
+			wantCursor: 3,
+			wantTexts: []string{
+				".",
+				"└── main",
+				"    ├── feat-a [+2]",
+				"    └── feat-b* [+1]",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newStackModel()
+			for _, k := range tt.keys {
+				m = update(t, m, key(k))
+			}
+			assert.Equal(t, tt.wantCursor, m.cursor)
+			if tt.wantTexts != nil {
+				assert.Equal(t, tt.wantTexts, itemTexts(m))
+			}
+			assert.Len(t, m.verboseLines, len(m.items))
+		})
+	}
+}
+
+func TestModel_ExpandToDetachedHead(t *testing.T) {
+	head := tree.NewNode("HEAD", time.Time{})
+	head.IsDetached = true
+	head.IsCurrent = true
+	head.Hash = "abc1234def5678"
+	main := tree.NewNode("main", time.Time{})
+	main.AddChild(head)
+	root := tree.NewNode(".", time.Time{})
+	root.AddChild(main)
+
+	m := NewModel(&tree.Tree{Root: root}, nil, nil)
+	m = update(t, m, key("c"), key("e"))
+
+	assert.Equal(t, 2, m.cursor)
+	assert.Equal(t, []string{".", "└── main", "    └── HEAD (detached at abc1234)*"}, itemTexts(m))
+}
@@ -1,7 +1,15 @@
 package main
 
-import "github.com/mucansever/gittree/cmd"
+import (
+	"fmt"
+	"os"
+
+	"github.com/mucansever/gittree/cmd"
+)
 
 func main() {
-	cmd.Execute()
+	if err := cmd.Execute(); err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(1)
+	}
 }